@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/reconcile"
+)
+
+// Recompute every player's balance from the ledger and report drift, the
+// same check the server runs nightly:
+//
+//	go run ./cmd/balancecheck
+//
+// Exits with status 1 when any balance drifted.
+func main() {
+	alert := flag.Bool("alert", false, "also post drift to BALANCE_ALERT_WEBHOOK_URL")
+	flag.Parse()
+
+	db := config.SetupDatabase()
+	webhook := ""
+	if *alert {
+		webhook = os.Getenv("BALANCE_ALERT_WEBHOOK_URL")
+	}
+
+	report, err := reconcile.RunBalanceCheck(db, "cli", webhook)
+	if err != nil {
+		log.Fatalf("[FATAL] Balance check failed: %v", err)
+	}
+
+	log.Printf("✅ Report %d: %d users checked, %d drifted (%s in total), %d not yet in the ledger",
+		report.ID, report.Users, report.Drifted, report.TotalDrift, report.Unbooked)
+	if report.Drifted > 0 {
+		os.Exit(1)
+	}
+}
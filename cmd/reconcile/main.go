@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/reconcile"
+)
+
+// Reconcile a bank or mobile-money statement CSV against recorded deposits:
+//
+//	go run ./cmd/reconcile -file statement.csv -preset telebirr -out report.csv
+func main() {
+	file := flag.String("file", "", "statement CSV to import (required)")
+	preset := flag.String("preset", "generic", "column mapping preset: telebirr, cbe, generic")
+	refCol := flag.String("reference-col", "", "override: reference column header")
+	amountCol := flag.String("amount-col", "", "override: amount column header")
+	dateCol := flag.String("date-col", "", "override: date column header")
+	payerCol := flag.String("payer-col", "", "override: payer column header")
+	dateLayout := flag.String("date-layout", "", "override: Go time layout of the date column")
+	delimiter := flag.String("delimiter", "", "override: field delimiter")
+	skipRows := flag.Int("skip-rows", -1, "override: banner lines before the header")
+	provider := flag.String("provider", "", "only compare deposits from this provider")
+	from := flag.String("from", "", "period start (YYYY-MM-DD), defaults to first statement date")
+	to := flag.String("to", "", "period end (YYYY-MM-DD), defaults to last statement date")
+	out := flag.String("out", "", "write the report CSV here (default stdout)")
+	save := flag.Bool("save", true, "store the report so it can be downloaded from the admin API")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	mapping, ok := reconcile.Presets[*preset]
+	if !ok {
+		log.Fatalf("[FATAL] unknown preset %q", *preset)
+	}
+	override := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	override(&mapping.Reference, *refCol)
+	override(&mapping.Amount, *amountCol)
+	override(&mapping.Date, *dateCol)
+	override(&mapping.Payer, *payerCol)
+	override(&mapping.DateLayout, *dateLayout)
+	if *delimiter != "" {
+		mapping.Delimiter = []rune(*delimiter)[0]
+	}
+	if *skipRows >= 0 {
+		mapping.SkipRows = *skipRows
+	}
+
+	opts := reconcile.Options{Source: *file, Provider: *provider}
+	opts.From = parseDay(*from)
+	opts.To = parseDay(*to)
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("[FATAL] %v", err)
+	}
+	defer f.Close()
+
+	rows, err := reconcile.ParseStatement(f, mapping)
+	if err != nil {
+		log.Fatalf("[FATAL] Failed to read statement: %v", err)
+	}
+
+	db := config.SetupDatabase()
+	report, entries, err := reconcile.Run(db, rows, opts)
+	if err != nil {
+		log.Fatalf("[FATAL] Reconciliation failed: %v", err)
+	}
+	report.CreatedBy = "cli"
+	if *save {
+		if err := db.Create(report).Error; err != nil {
+			log.Fatalf("[FATAL] Failed to store report: %v", err)
+		}
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		if w, err = os.Create(*out); err != nil {
+			log.Fatalf("[FATAL] %v", err)
+		}
+		defer w.Close()
+	}
+	if err := reconcile.WriteCSV(w, entries); err != nil {
+		log.Fatalf("[FATAL] Failed to write report: %v", err)
+	}
+
+	log.Printf("✅ Report %d: %d matched, %d amount mismatches, %d only on statement, %d only in DB",
+		report.ID, report.Matched, report.AmountMismatch, report.UnmatchedStatement, report.UnmatchedDB)
+}
+
+func parseDay(s string) *time.Time {
+	if s == "" {
+		return nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		log.Fatalf("[FATAL] bad date %q, want YYYY-MM-DD", s)
+	}
+	return &t
+}
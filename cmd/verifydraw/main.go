@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/game"
+	"github.com/bellapacxx/bingo-backend/services"
+)
+
+// Recompute the draw of a provably fair round, either from a finished game
+// in the database:
+//
+//	go run ./cmd/verifydraw -game 123
+//
+// or offline from the seeds a round revealed, without trusting the server:
+//
+//	go run ./cmd/verifydraw -server-seed <seed> -client-seed <seed> -commitment <hash>
+//
+// Exits with status 1 when the seed doesn't match its commitment or the game
+// drew different balls.
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout))
+}
+
+// run is the command without the process around it; it returns the exit
+// status.
+func run(args []string, stdout io.Writer) int {
+	flags := flag.NewFlagSet("verifydraw", flag.ContinueOnError)
+	gameID := flags.Uint("game", 0, "game to verify from the database")
+	serverSeed := flags.String("server-seed", "", "revealed server seed")
+	clientSeed := flags.String("client-seed", "", "round's combined client seed")
+	commitment := flags.String("commitment", "", "server seed hash published before the round")
+	balls := flags.Int("balls", 75, "balls in the draw")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *gameID != 0 {
+		v, err := services.VerifyDraw(config.SetupDatabase(), uint(*gameID))
+		if err != nil {
+			log.Printf("[FATAL] Failed to verify game %d: %v", *gameID, err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Game %d (%s)\n", v.GameID, v.Algorithm)
+		fmt.Fprintf(stdout, "Server seed: %s\nCommitment:  %s\nClient seed: %q\n", v.ServerSeed, v.ServerSeedHash, v.ClientSeed)
+		fmt.Fprintf(stdout, "Draw order:  %v\nDrawn:       %v\n", v.DrawOrder, v.Drawn)
+		if !v.Verified {
+			log.Printf("❌ commitment ok: %t, draw ok: %t", v.CommitmentOK, v.DrawOK)
+			return 1
+		}
+		log.Printf("✅ Game %d was drawn from its committed seeds", v.GameID)
+		return 0
+	}
+
+	if *serverSeed == "" {
+		flags.Usage()
+		return 2
+	}
+	fmt.Fprintf(stdout, "Draw order: %v\n", game.DrawOrder(*serverSeed, *clientSeed, *balls))
+	if *commitment != "" {
+		if game.Commit(*serverSeed) != *commitment {
+			log.Printf("❌ server seed does not match the commitment")
+			return 1
+		}
+		log.Printf("✅ server seed matches the commitment")
+	}
+	return 0
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/bellapacxx/bingo-backend/game"
+)
+
+const (
+	testServerSeed = "5f0c2a7e9d3b41c6a8e2f1d07b9c3e54a6d8f20e1c4b7a9d3e5f60718293a4b5"
+	testClientSeed = "7:lucky,12:seven"
+	testCommitment = "b06f00c17c65fa2be58f895d642b13fb3446a72d89c1d33c6d1f891069a46339"
+)
+
+// The same known answer the game package pins for FairAlgorithm.
+var testOrder = []int{
+	53, 58, 13, 10, 40, 34, 68, 11, 1, 38, 27, 61, 18, 31, 69, 17, 21, 29, 25, 39, 37, 19, 2, 66, 23,
+	52, 45, 73, 4, 62, 30, 55, 8, 16, 71, 9, 24, 6, 22, 51, 64, 59, 3, 14, 48, 72, 28, 57, 20, 26,
+	32, 7, 5, 65, 63, 35, 70, 47, 46, 56, 12, 43, 33, 54, 44, 60, 36, 50, 42, 74, 67, 49, 15, 75, 41,
+}
+
+func init() { log.SetOutput(io.Discard) }
+
+func TestOfflineVerifyAgreesWithEngine(t *testing.T) {
+	var out bytes.Buffer
+	status := run([]string{"-server-seed", testServerSeed, "-client-seed", testClientSeed, "-commitment", testCommitment}, &out)
+	if status != 0 {
+		t.Fatalf("exit status %d, want 0; output:\n%s", status, out.String())
+	}
+	want := fmt.Sprintf("Draw order: %v\n", testOrder)
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+	if got := fmt.Sprintf("Draw order: %v\n", game.DrawOrder(testServerSeed, testClientSeed, 75)); got != out.String() {
+		t.Errorf("verifydraw printed %q, game.DrawOrder gives %q", out.String(), got)
+	}
+}
+
+func TestOfflineVerifyBalls(t *testing.T) {
+	var out bytes.Buffer
+	if status := run([]string{"-server-seed", "abc", "-client-seed", "1:x", "-balls", "10"}, &out); status != 0 {
+		t.Fatalf("exit status %d, want 0", status)
+	}
+	if want := "Draw order: [5 9 4 2 7 6 8 3 1 10]\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestOfflineVerifyRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"wrong commitment", []string{"-server-seed", testServerSeed, "-commitment", game.Commit("other")}, 1},
+		{"no server seed", []string{"-client-seed", testClientSeed}, 2},
+		{"unknown flag", []string{"-seed", testServerSeed}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := run(tt.args, io.Discard); status != tt.want {
+				t.Errorf("exit status %d, want %d", status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/walletstress"
+)
+
+// Hammer wallets with concurrent deposits, stakes and payouts, then check
+// that every balance is exactly what the operations add up to. Run it
+// against a disposable database:
+//
+//	DATABASE_URL=postgres://... go run ./cmd/walletstress -users 20 -workers 32 -ops 2000
+func main() {
+	var opts walletstress.Options
+	flag.IntVar(&opts.Users, "users", 10, "players to create")
+	flag.IntVar(&opts.Workers, "workers", 16, "concurrent goroutines")
+	flag.IntVar(&opts.Ops, "ops", 1000, "operations per worker")
+	flag.Parse()
+
+	config.SetupDatabase()
+	result, err := walletstress.Run(opts)
+	if err != nil {
+		log.Fatalf("[FATAL] %v", err)
+	}
+
+	for _, err := range result.Failures {
+		log.Printf("[walletstress] %v", err)
+	}
+	for _, m := range result.Mismatches {
+		log.Printf("❌ %s", m)
+	}
+	if len(result.Unbalanced) > 0 {
+		log.Printf("❌ journal entries whose postings don't sum to zero: %v", result.Unbalanced)
+	}
+
+	log.Printf("%d operations, %d failed, %d balance mismatches", result.Operations, len(result.Failures), len(result.Mismatches))
+	if !result.OK() {
+		os.Exit(1)
+	}
+	log.Printf("✅ All balances exact")
+}
@@ -44,15 +44,47 @@ func SetupDatabase() *gorm.DB {
 		DB = db
 
 		// Run migrations
+		if err := migrateMoneyColumns(db); err != nil {
+			log.Fatalf("[FATAL] Money column migration failed: %v", err)
+		}
 		if err := db.AutoMigrate(
 			&models.User{},
 			&models.Game{},
 			&models.Card{},
 			&models.Transaction{},
 			&models.Deposit{},
+			&models.LedgerAccount{},
+			&models.JournalEntry{},
+			&models.LedgerPosting{},
+			&models.StakeHold{},
+			&models.IdempotencyKey{},
+			&models.WithdrawalRequest{},
+			&models.DepositIntent{},
+			&models.ReconciliationReport{},
+			&models.BalanceReport{},
+			&models.GamingLimit{},
+			&models.SelfExclusion{},
+			&models.BonusPromotion{},
+			&models.BonusGrant{},
+			&models.Referral{},
+			&models.VoucherBatch{},
+			&models.Voucher{},
+			&models.VoucherRedemption{},
+			&models.VoucherAttempt{},
+			&models.LobbySettings{},
+			&models.RoundEntry{},
 		); err != nil {
 			log.Fatalf("[FATAL] Migration failed: %v", err)
 		}
+		if err := openLedgerWallets(db); err != nil {
+			log.Fatalf("[FATAL] Opening balance migration failed: %v", err)
+		}
+		// Bonus winnings recorded before transactions named their wallet
+		if err := db.Model(&models.Transaction{}).
+			Where("type = ? AND wallet = ?", models.BonusWinTransaction, models.WalletAccount).
+			Update("wallet", models.BonusWalletAccount).Error; err != nil {
+			log.Fatalf("[FATAL] Transaction wallet migration failed: %v", err)
+		}
 
 		log.Println("✅ Database connected and migration completed")
 	})
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/bellapacxx/bingo-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Account codes as services names them; config can't import services.
+const (
+	openingEquityCode = "equity:opening"
+	walletCodeFormat  = "wallet:%d"
+)
+
+// openLedgerWallets books the balance of every user who has money in
+// users.balance but no ledger wallet yet as an opening balance from opening
+// equity. It is the only place opening balances are posted, and it skips
+// users that already have a wallet, so it is safe to run on every start.
+func openLedgerWallets(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var users []models.User
+		if err := tx.Where("balance <> 0").
+			Where("NOT EXISTS (SELECT 1 FROM ledger_accounts a WHERE a.code = 'wallet:' || users.id)").
+			Find(&users).Error; err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return nil
+		}
+
+		equity := models.LedgerAccount{Code: openingEquityCode, Type: models.EquityAccount}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&equity).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("code = ?", openingEquityCode).First(&equity).Error; err != nil {
+			return err
+		}
+
+		for _, u := range users {
+			userID := u.ID
+			wallet := models.LedgerAccount{
+				Code:    fmt.Sprintf(walletCodeFormat, userID),
+				Type:    models.WalletAccount,
+				UserID:  &userID,
+				Balance: u.Balance,
+			}
+			if err := tx.Create(&wallet).Error; err != nil {
+				return err
+			}
+			entry := models.JournalEntry{Kind: models.OpeningBalanceEntry, UserID: &userID}
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+			// users.balance already holds this money, so only the ledger side moves
+			if err := tx.Create(&[]models.LedgerPosting{
+				{EntryID: entry.ID, AccountID: equity.ID, Amount: -u.Balance},
+				{EntryID: entry.ID, AccountID: wallet.ID, Amount: u.Balance},
+			}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&equity).Update("balance", gorm.Expr("balance - ?", u.Balance)).Error; err != nil {
+				return err
+			}
+		}
+		log.Printf("[Migrate] opened ledger wallets for %d users with existing balances", len(users))
+		return nil
+	})
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// moneyColumns lists every column that used to hold birr as a float and now
+// holds santim as a bigint.
+var moneyColumns = []struct{ Table, Column string }{
+	{"users", "balance"},
+	{"transactions", "amount"},
+	{"transactions", "balance_after"},
+	{"deposits", "amount"},
+	{"ledger_accounts", "balance"},
+	{"ledger_postings", "amount"},
+}
+
+// migrateMoneyColumns converts legacy float birr columns to integer santim
+// before AutoMigrate runs. Left to AutoMigrate, the type change would just
+// cast 12.5 to 13 birr; here every value is scaled by 100 and rounded once.
+// Columns that are already integers, or tables that don't exist yet, are
+// skipped, so it is safe to run on every start.
+func migrateMoneyColumns(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, mc := range moneyColumns {
+			var dataType string
+			err := tx.Raw(`SELECT data_type FROM information_schema.columns
+				WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?`,
+				mc.Table, mc.Column).Scan(&dataType).Error
+			if err != nil {
+				return err
+			}
+			if dataType != "double precision" && dataType != "real" && dataType != "numeric" {
+				continue
+			}
+
+			stmt := fmt.Sprintf(`ALTER TABLE %q ALTER COLUMN %q TYPE bigint USING ROUND(%q * 100)::bigint`,
+				mc.Table, mc.Column, mc.Column)
+			if err := tx.Exec(stmt).Error; err != nil {
+				return fmt.Errorf("convert %s.%s to santim: %w", mc.Table, mc.Column, err)
+			}
+			log.Printf("[Migrate] converted %s.%s from %s birr to bigint santim", mc.Table, mc.Column, dataType)
+		}
+		return nil
+	})
+}
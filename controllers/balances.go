@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/models"
+	"github.com/bellapacxx/bingo-backend/reconcile"
+	"github.com/gin-gonic/gin"
+)
+
+// RunBalanceCheck checks every player balance now instead of waiting for
+// the nightly job
+func RunBalanceCheck(c *gin.Context) {
+	report, err := reconcile.RunBalanceCheck(config.DB, "admin", os.Getenv("BALANCE_ALERT_WEBHOOK_URL"))
+	if err != nil {
+		log.Printf("[ERROR] Balance check failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "balance check failed"})
+		return
+	}
+	c.JSON(http.StatusCreated, report)
+}
+
+// ListBalanceReports returns stored balance checks without their lines
+func ListBalanceReports(c *gin.Context) {
+	var reports []models.BalanceReport
+	if err := config.DB.Omit("entries").Order("id DESC").Limit(100).Find(&reports).Error; err != nil {
+		log.Printf("[ERROR] Failed to fetch balance reports: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, reports)
+}
+
+// GetBalanceReport returns one balance check with every drifted player
+func GetBalanceReport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report id"})
+		return
+	}
+	var report models.BalanceReport
+	if err := config.DB.First(&report, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
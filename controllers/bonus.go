@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/middleware"
+	"github.com/bellapacxx/bingo-backend/models"
+	"github.com/bellapacxx/bingo-backend/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetBonus returns the player's bonus balance and grants, with how much
+// wagering each grant still needs
+func GetBonus(c *gin.Context) {
+	user, ok := userFromParam(c)
+	if !ok {
+		return
+	}
+	grants, err := services.UserBonuses(config.DB, user.ID)
+	if err != nil {
+		log.Printf("[ERROR] Failed to fetch bonuses for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	type grantView struct {
+		models.BonusGrant
+		WageringLeft models.Money `json:"wageringLeft"`
+	}
+	views := make([]grantView, 0, len(grants))
+	for _, g := range grants {
+		v := grantView{BonusGrant: g}
+		if g.Status == models.BonusActive {
+			v.WageringLeft = max(g.WageringRequired-g.Wagered, 0)
+		}
+		views = append(views, v)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"cashBalance":  user.Balance,
+		"bonusBalance": user.BonusBalance,
+		"fundingOrder": services.StakeFundingOrder(),
+		"grants":       views,
+	})
+}
+
+// ListBonusPromotions lists every bonus promotion
+func ListBonusPromotions(c *gin.Context) {
+	var promos []models.BonusPromotion
+	if err := config.DB.Order("id DESC").Find(&promos).Error; err != nil {
+		log.Printf("[ERROR] Failed to fetch bonus promotions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, promos)
+}
+
+// CreateBonusPromotion adds a promotion: how many times the bonus must be
+// staked, how long it lasts and where bonus-stake winnings go
+func CreateBonusPromotion(c *gin.Context) {
+	var req struct {
+		Code               string              `json:"code" binding:"required"`
+		Name               string              `json:"name"`
+		WageringMultiplier int                 `json:"wageringMultiplier"`
+		ValidDays          int                 `json:"validDays" binding:"required"`
+		WinningsTo         models.WinningsRule `json:"winningsTo"`
+		Active             *bool               `json:"active"` // defaults to true
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	promo := &models.BonusPromotion{
+		Code:               req.Code,
+		Name:               req.Name,
+		WageringMultiplier: req.WageringMultiplier,
+		ValidDays:          req.ValidDays,
+		WinningsTo:         req.WinningsTo,
+		Active:             req.Active == nil || *req.Active,
+	}
+	if err := services.CreatePromotion(promo); err != nil {
+		if errors.Is(err, services.ErrInvalidPromotion) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("[ERROR] Failed to create bonus promotion %q: %v", req.Code, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusCreated, promo)
+}
+
+// GrantBonus gives a player bonus money under a promotion
+func GrantBonus(c *gin.Context) {
+	var req struct {
+		PromotionID uint         `json:"promotionId" binding:"required"`
+		Amount      models.Money `json:"amount" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := userFromParam(c)
+	if !ok {
+		return
+	}
+	grant, err := services.GrantBonus(config.DB, user.ID, req.PromotionID, req.Amount, middleware.AdminUser(c))
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promotion not found"})
+		return
+	case errors.Is(err, services.ErrInvalidPromotion), errors.Is(err, services.ErrPromotionInactive):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("[ERROR] Failed to grant bonus to user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	// Told only now that the grant has committed, as for referral rewards
+	services.NotifyPromotion(user.ID, "🎁 You received a bonus of "+req.Amount.String())
+	c.JSON(http.StatusCreated, grant)
+}
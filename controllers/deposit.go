@@ -1,29 +1,40 @@
 package controllers
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/bellapacxx/bingo-backend/config"
 	"github.com/bellapacxx/bingo-backend/models"
+	"github.com/bellapacxx/bingo-backend/payments"
+	"github.com/bellapacxx/bingo-backend/services"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type VerifyDepositRequest struct {
-	UserID         int    `json:"userId" binding:"required"`         // Telegram ID
-	ExpectedAmount int    `json:"expectedAmount" binding:"required"` // Amount expected
-	Reference      string `json:"reference" binding:"required"`      // Reference code
+	UserID         int          `json:"userId" binding:"required"`         // Telegram ID
+	ExpectedAmount models.Money `json:"expectedAmount" binding:"required"` // Amount the player says they sent, in birr
+	Reference      string       `json:"reference" binding:"required"`      // Provider transaction reference
+	Provider       string       `json:"provider"`                          // optional, defaults to DEFAULT_DEPOSIT_PROVIDER
 }
 
-// VerifyDeposit updates user balance after deposit verification
+// VerifyDeposit checks a payment reference with the provider and credits the
+// amount the provider confirms. If it differs from what the player expected,
+// or the provider's payer isn't the player's phone, the deposit is recorded
+// as disputed and left for an operator.
 func VerifyDeposit(c *gin.Context) {
-	log.Printf("masgds")
 	var req VerifyDepositRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.ExpectedAmount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expectedAmount must be positive"})
+		return
+	}
 
 	var user models.User
 	if err := config.DB.Where("telegram_id = ?", req.UserID).First(&user).Error; err != nil {
@@ -36,35 +47,134 @@ func VerifyDeposit(c *gin.Context) {
 		return
 	}
 
-	// Prevent double deposits
-	var depositExists int64
-	config.DB.Model(&models.Deposit{}).Where("reference = ?", req.Reference).Count(&depositExists)
-	if depositExists > 0 {
-		c.JSON(http.StatusConflict, gin.H{"error": "deposit with this reference already processed"})
+	verifier, err := payments.Lookup(req.Provider)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	deposit := models.Deposit{
+		UserID:         user.ID,
+		ExpectedAmount: req.ExpectedAmount,
+		Reference:      req.Reference,
+		Provider:       verifier.Name(),
+	}
+	err = services.ConfirmDeposit(c.Request.Context(), verifier, &user, &deposit)
+	if errors.Is(err, payments.ErrReferenceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "payment not found with provider"})
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] %s verification failed for %s: %v", verifier.Name(), req.Reference, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "could not verify payment, try again later"})
 		return
 	}
 
-	// Update balance
-	user.Balance += float64(req.ExpectedAmount)
-	if err := config.DB.Save(&user).Error; err != nil {
-		log.Printf("[ERROR] Failed to update balance: %v", err)
+	err = services.RecordDeposit(config.DB, &deposit)
+	if errors.Is(err, services.ErrDuplicateDeposit) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if limitBlocked(c, user.ID, err) || exclusionBlocked(c, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to record deposit %s: %v", req.Reference, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update balance"})
 		return
 	}
 
-	// Record the deposit
-	if err := config.DB.Create(&models.Deposit{
-		UserID:    user.ID,
-		Amount:    float64(req.ExpectedAmount),
-		Reference: req.Reference,
-	}).Error; err != nil {
-		log.Printf("[ERROR] Failed to record deposit: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record deposit"})
+	if deposit.Status == models.DepositDisputed {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   deposit.DisputeReason + ", deposit is under review",
+			"deposit": deposit,
+		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Balance updated successfully",
+		"amount":  deposit.Amount,
 	})
 }
+
+// Deposit serves the old POST /api/deposit, which credited whatever amount
+// the client sent. It now takes a VerifyDepositRequest and goes through
+// VerifyDeposit like POST /api/deposit/verify, which clients should call
+// instead.
+//
+// Deprecated: use VerifyDeposit.
+func Deposit(c *gin.Context) {
+	c.Header("Deprecation", "true")
+	c.Header("Link", `</api/deposit/verify>; rel="successor-version"`)
+	VerifyDeposit(c)
+}
+
+// AdminListDeposits lists deposits, optionally by status (e.g. disputed)
+func AdminListDeposits(c *gin.Context) {
+	q := config.DB.Order("id DESC")
+	if status := c.Query("status"); status != "" {
+		q = q.Where("status = ?", status)
+	}
+
+	var deposits []models.Deposit
+	if err := q.Find(&deposits).Error; err != nil {
+		log.Printf("[ERROR] Failed to fetch deposits: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, deposits)
+}
+
+// ResolveDeposit credits a disputed deposit with the provider-confirmed amount
+func ResolveDeposit(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deposit id"})
+		return
+	}
+
+	deposit, err := services.ResolveDisputedDeposit(config.DB, uint(id))
+	if errors.Is(err, services.ErrDepositNotOpen) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if exclusionBlocked(c, err) {
+		return
+	}
+	// Unlike limitBlocked this only tells the operator; for the player the
+	// deposit simply stays under review
+	var limitErr *services.LimitError
+	if errors.As(err, &limitErr) {
+		c.JSON(http.StatusForbidden, gin.H{"error": limitErr.Error(), "limit": limitErr.Limit})
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to resolve deposit %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to resolve deposit"})
+		return
+	}
+	c.JSON(http.StatusOK, deposit)
+}
+
+// limitBlocked answers 403 and tells the player over the WebSocket when err
+// is a responsible-gaming limit.
+func limitBlocked(c *gin.Context, userID uint, err error) bool {
+	var limitErr *services.LimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+	services.NotifyUser(userID, "⛔ "+limitErr.Error())
+	c.JSON(http.StatusForbidden, gin.H{"error": limitErr.Error(), "limit": limitErr.Limit})
+	return true
+}
+
+// exclusionBlocked answers 403 when err says the player is self-excluded.
+func exclusionBlocked(c *gin.Context, err error) bool {
+	var excluded *services.ExcludedError
+	if !errors.As(err, &excluded) {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": excluded.Error(), "exclusion": excluded.Exclusion})
+	return true
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/models"
+	"github.com/bellapacxx/bingo-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// SelfExclude keeps the player out of play for 24h, 7d, 30d or permanently.
+// Withdrawals keep working.
+func SelfExclude(c *gin.Context) {
+	var req struct {
+		Period string `json:"period" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := userFromParam(c)
+	if !ok {
+		return
+	}
+	exclusion, err := services.ExcludeUser(user.ID, req.Period)
+	if errors.Is(err, services.ErrUnknownExclusionPeriod) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to exclude user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusCreated, exclusion)
+}
+
+// GetSelfExclusion returns the player's running exclusion, if any
+func GetSelfExclusion(c *gin.Context) {
+	user, ok := userFromParam(c)
+	if !ok {
+		return
+	}
+	exclusion, err := services.ActiveExclusion(config.DB, user.ID)
+	if err != nil {
+		log.Printf("[ERROR] Failed to fetch exclusion for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"excluded": exclusion != nil, "exclusion": exclusion})
+}
+
+// AdminListExclusions lists exclusions in force right now
+func AdminListExclusions(c *gin.Context) {
+	var rows []struct {
+		models.SelfExclusion
+		TelegramID int64  `json:"telegramId"`
+		Name       string `json:"name"`
+	}
+	now := time.Now()
+	if err := config.DB.Table("self_exclusions").
+		Select("self_exclusions.*, users.telegram_id, users.name").
+		Joins("JOIN users ON users.id = self_exclusions.user_id").
+		Where("self_exclusions.starts_at <= ? AND (self_exclusions.ends_at IS NULL OR self_exclusions.ends_at > ?)", now, now).
+		Order("self_exclusions.id DESC").
+		Scan(&rows).Error; err != nil {
+		log.Printf("[ERROR] Failed to fetch exclusions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// SendPromotion pushes a promotional message to every connected player who
+// isn't self-excluded
+func SendPromotion(c *gin.Context) {
+	var req struct {
+		Message string `json:"message" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sent, skipped := 0, 0
+	for _, userID := range services.ConnectedUsers() {
+		if services.NotifyPromotion(userID, req.Message) {
+			sent++
+		} else {
+			skipped++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"sent": sent, "skipped": skipped})
+}
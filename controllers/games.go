@@ -1,14 +1,18 @@
 package controllers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/bellapacxx/bingo-backend/config"
 	"github.com/bellapacxx/bingo-backend/game"
 	"github.com/bellapacxx/bingo-backend/models"
+	"github.com/bellapacxx/bingo-backend/services"
 	"github.com/bellapacxx/bingo-backend/utils/logger"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // In-memory lobbies map (gameID -> Lobby)
@@ -35,6 +39,28 @@ func GetGame(c *gin.Context) {
 	c.JSON(http.StatusOK, game)
 }
 
+// VerifyDraw reveals a finished game's seeds and recomputes its draw
+func VerifyDraw(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid game ID"})
+		return
+	}
+
+	v, err := services.VerifyDraw(config.DB, uint(id))
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+	case errors.Is(err, services.ErrSeedNotRevealed), errors.Is(err, services.ErrNoSeeds):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("[ERROR] Failed to verify draw of game %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify draw"})
+	default:
+		c.JSON(http.StatusOK, v)
+	}
+}
+
 // JoinGame adds a player to a lobby
 func JoinGame(c *gin.Context) {
 	gameIDStr := c.Param("id")
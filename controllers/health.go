@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/reconcile"
+	"github.com/gin-gonic/gin"
+)
+
+// Health reports liveness plus the outcome of the last balance check. The
+// status reads "degraded" while any player balance has drifted.
+func Health(c *gin.Context) {
+	resp := gin.H{"status": "ok", "timestamp": time.Now()}
+
+	report, err := reconcile.LatestBalanceReport(config.DB)
+	switch {
+	case err != nil:
+		log.Printf("[ERROR] Failed to load latest balance report: %v", err)
+		resp["balances"] = gin.H{"error": "unavailable"}
+	case report != nil:
+		resp["balances"] = gin.H{
+			"reportId":  report.ID,
+			"checkedAt": report.CreatedAt,
+			"drifted":   report.Drifted,
+		}
+		if report.Drifted > 0 {
+			resp["status"] = "degraded"
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/middleware"
+	"github.com/bellapacxx/bingo-backend/models"
+	"github.com/bellapacxx/bingo-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ListLobbySettings returns the payout rules of every stake lobby
+func ListLobbySettings(c *gin.Context) {
+	all := make([]models.LobbySettings, 0, len(services.Stakes))
+	for _, stake := range services.Stakes {
+		s, err := services.LobbySettingsFor(stake)
+		if err != nil {
+			log.Printf("[ERROR] Failed to fetch settings for lobby %d: %v", stake, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		all = append(all, s)
+	}
+	c.JSON(http.StatusOK, all)
+}
+
+// UpdateLobbySettings changes a lobby's payout rules from its next round
+func UpdateLobbySettings(c *gin.Context) {
+	stake, err := strconv.Atoi(c.Param("stake"))
+	if err != nil || !slices.Contains(services.Stakes, stake) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lobby not found"})
+		return
+	}
+
+	var req struct {
+		PayoutBps *int         `json:"payoutBps" binding:"required"`
+		RakeBps   *int         `json:"rakeBps" binding:"required"`
+		MinPot    models.Money `json:"minPot"`
+		MaxPayout models.Money `json:"maxPayout"`
+		// Optional, defaults apply when left out
+		ClaimWindowMS   *int                 `json:"claimWindowMs"`
+		SplitRounding   models.SplitRounding `json:"splitRounding"`
+		JackpotBps      int                  `json:"jackpotBps"`
+		JackpotMaxBalls int                  `json:"jackpotMaxBalls"`
+		Patterns        []string             `json:"patterns"` // library names, empty for the classic set
+		MaxCards        *int                 `json:"maxCards"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings := models.DefaultLobbySettings(stake)
+	settings.PayoutBps = *req.PayoutBps
+	settings.RakeBps = *req.RakeBps
+	settings.MinPot = req.MinPot
+	settings.MaxPayout = req.MaxPayout
+	settings.JackpotBps = req.JackpotBps
+	settings.JackpotMaxBalls = req.JackpotMaxBalls
+	settings.Patterns = req.Patterns
+	settings.UpdatedBy = middleware.AdminUser(c)
+	if req.ClaimWindowMS != nil {
+		settings.ClaimWindowMS = *req.ClaimWindowMS
+	}
+	if req.SplitRounding != "" {
+		settings.SplitRounding = req.SplitRounding
+	}
+	if req.MaxCards != nil {
+		settings.MaxCards = *req.MaxCards
+	}
+	if err := services.SaveLobbySettings(&settings); err != nil {
+		if errors.Is(err, models.ErrInvalidLobbySettings) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("[ERROR] Failed to save settings for lobby %d: %v", stake, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// RoundRevenue lists what the house earned per round, with totals.
+// Filters: stake, from and to (YYYY-MM-DD, inclusive), limit (default 100).
+func RoundRevenue(c *gin.Context) {
+	f := services.RevenueFilter{Limit: 100}
+	if v := c.Query("stake"); v != "" {
+		stake, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid stake"})
+			return
+		}
+		f.Stake = stake
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+		f.Limit = limit
+	}
+	for field, dst := range map[string]**time.Time{"from": &f.From, "to": &f.To} {
+		if v := c.Query(field); v != "" {
+			t, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": field + " must be YYYY-MM-DD"})
+				return
+			}
+			if field == "to" {
+				t = t.AddDate(0, 0, 1)
+			}
+			*dst = &t
+		}
+	}
+
+	rounds, err := services.RoundRevenue(config.DB, f)
+	if err != nil {
+		log.Printf("[ERROR] Failed to compute round revenue: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	var totals services.GameRevenue
+	for _, r := range rounds {
+		totals.Players += r.Players
+		totals.Stakes += r.Stakes
+		totals.Payout += r.Payout
+		totals.Rake += r.Rake
+		totals.Subsidy += r.Subsidy
+		totals.JackpotFeed += r.JackpotFeed
+		totals.JackpotPaid += r.JackpotPaid
+		totals.Net += r.Net
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"rounds": rounds,
+		"totals": gin.H{
+			"rounds":      len(rounds),
+			"players":     totals.Players,
+			"stakes":      totals.Stakes,
+			"payout":      totals.Payout,
+			"rake":        totals.Rake,
+			"subsidy":     totals.Subsidy,
+			"jackpotFeed": totals.JackpotFeed,
+			"jackpotPaid": totals.JackpotPaid,
+			"net":         totals.Net,
+		},
+	})
+}
@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/models"
+	"github.com/bellapacxx/bingo-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// limitView shows a limit in birr or rounds, depending on its kind
+type limitView struct {
+	Kind          models.LimitKind   `json:"kind"`
+	Period        models.LimitPeriod `json:"period"`
+	Amount        *models.Money      `json:"amount,omitempty"`
+	Rounds        *int64             `json:"rounds,omitempty"`
+	PendingAmount *models.Money      `json:"pendingAmount,omitempty"` // 0 when being removed
+	PendingRounds *int64             `json:"pendingRounds,omitempty"`
+	PendingFrom   *time.Time         `json:"pendingFrom,omitempty"`
+}
+
+func newLimitView(l models.GamingLimit) limitView {
+	v := limitView{Kind: l.Kind, Period: l.Period, PendingFrom: l.PendingFrom}
+	if l.Kind == models.RoundsLimit {
+		v.Rounds, v.PendingRounds = &l.Value, l.Pending
+		return v
+	}
+	amount := models.Money(l.Value)
+	v.Amount = &amount
+	if l.Pending != nil {
+		pending := models.Money(*l.Pending)
+		v.PendingAmount = &pending
+	}
+	return v
+}
+
+// GetLimits returns a player's responsible-gaming limits
+func GetLimits(c *gin.Context) {
+	user, ok := userFromParam(c)
+	if !ok {
+		return
+	}
+	limits, err := services.UserLimits(config.DB, user.ID)
+	if err != nil {
+		log.Printf("[ERROR] Failed to fetch limits for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	views := make([]limitView, 0, len(limits))
+	for _, l := range limits {
+		views = append(views, newLimitView(l))
+	}
+	c.JSON(http.StatusOK, gin.H{"limits": views, "coolingOffHours": services.LimitCoolingOff.Hours()})
+}
+
+// SetLimit sets, lowers, raises or removes (amount/rounds 0 or left out) one
+// limit. Raising and removing take effect after the cooling-off period.
+func SetLimit(c *gin.Context) {
+	var req struct {
+		Kind   models.LimitKind   `json:"kind" binding:"required"`
+		Period models.LimitPeriod `json:"period" binding:"required"`
+		Amount models.Money       `json:"amount"` // deposit and loss limits, in birr
+		Rounds int64              `json:"rounds"` // rounds limits
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Kind.Valid() || !req.Period.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be deposit, loss or rounds and period daily, weekly or monthly"})
+		return
+	}
+	value := int64(req.Amount)
+	if req.Kind == models.RoundsLimit {
+		value = req.Rounds
+	}
+	if value < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit can't be negative"})
+		return
+	}
+
+	user, ok := userFromParam(c)
+	if !ok {
+		return
+	}
+	limit, err := services.SetLimit(user.ID, req.Kind, req.Period, value)
+	if err != nil {
+		log.Printf("[ERROR] Failed to set %s %s limit for user %d: %v", req.Period, req.Kind, user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if limit == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "No limit set"})
+		return
+	}
+	c.JSON(http.StatusOK, newLimitView(*limit))
+}
+
+// userFromParam loads the user named by the :telegram_id path parameter
+func userFromParam(c *gin.Context) (*models.User, bool) {
+	tid, err := strconv.ParseInt(c.Param("telegram_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid telegram_id"})
+		return nil, false
+	}
+	var user models.User
+	if err := config.DB.Where("telegram_id = ?", tid).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	return &user, true
+}
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bellapacxx/bingo-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ListPatterns returns the winning-pattern library lobbies can choose from
+func ListPatterns(c *gin.Context) {
+	c.JSON(http.StatusOK, services.PatternLibrary())
+}
+
+// SetNextRoundPatterns overrides a lobby's patterns for its next round only
+func SetNextRoundPatterns(c *gin.Context) {
+	stake, err := strconv.Atoi(c.Param("stake"))
+	services.LobbiesMu.Lock()
+	lobby := services.Lobbies[stake]
+	services.LobbiesMu.Unlock()
+	if err != nil || lobby == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lobby not found"})
+		return
+	}
+
+	var req struct {
+		Patterns []string `json:"patterns" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	patterns, err := lobby.SetNextRoundPatterns(req.Patterns)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownPattern) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set patterns"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"stake": stake, "patterns": patterns})
+}
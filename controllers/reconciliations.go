@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/middleware"
+	"github.com/bellapacxx/bingo-backend/models"
+	"github.com/bellapacxx/bingo-backend/reconcile"
+	"github.com/gin-gonic/gin"
+)
+
+// UploadStatement imports a statement CSV (multipart field "file") and stores
+// the reconciliation report. The column mapping comes from the "preset" form
+// field, with optional per-column overrides.
+func UploadStatement(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "statement file is required"})
+		return
+	}
+
+	preset := c.DefaultPostForm("preset", "generic")
+	mapping, ok := reconcile.Presets[preset]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown preset %q", preset)})
+		return
+	}
+	for field, dst := range map[string]*string{
+		"referenceCol": &mapping.Reference,
+		"amountCol":    &mapping.Amount,
+		"dateCol":      &mapping.Date,
+		"payerCol":     &mapping.Payer,
+		"dateLayout":   &mapping.DateLayout,
+	} {
+		if v := c.PostForm(field); v != "" {
+			*dst = v
+		}
+	}
+	if v := c.PostForm("delimiter"); v != "" {
+		mapping.Delimiter = []rune(v)[0]
+	}
+	if v := c.PostForm("skipRows"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "skipRows must be a non-negative number"})
+			return
+		}
+		mapping.SkipRows = n
+	}
+
+	opts := reconcile.Options{Source: header.Filename, Provider: c.PostForm("provider")}
+	for field, dst := range map[string]**time.Time{"from": &opts.From, "to": &opts.To} {
+		if v := c.PostForm(field); v != "" {
+			t, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": field + " must be YYYY-MM-DD"})
+				return
+			}
+			*dst = &t
+		}
+	}
+
+	f, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "could not read statement file"})
+		return
+	}
+	defer f.Close()
+
+	rows, err := reconcile.ParseStatement(f, mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, _, err := reconcile.Run(config.DB, rows, opts)
+	if err != nil {
+		log.Printf("[ERROR] Reconciliation of %s failed: %v", header.Filename, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "reconciliation failed"})
+		return
+	}
+	report.CreatedBy = middleware.AdminUser(c)
+	if err := config.DB.Create(report).Error; err != nil {
+		log.Printf("[ERROR] Failed to store reconciliation report: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, report)
+}
+
+// ListReconciliations returns stored reports without their lines
+func ListReconciliations(c *gin.Context) {
+	var reports []models.ReconciliationReport
+	if err := config.DB.Omit("entries").Order("id DESC").Find(&reports).Error; err != nil {
+		log.Printf("[ERROR] Failed to fetch reconciliation reports: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, reports)
+}
+
+// GetReconciliation returns one report with all of its lines
+func GetReconciliation(c *gin.Context) {
+	report, ok := findReconciliation(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// DownloadReconciliation returns a report's lines as CSV
+func DownloadReconciliation(c *gin.Context) {
+	report, ok := findReconciliation(c)
+	if !ok {
+		return
+	}
+	entries, err := reconcile.Entries(report)
+	if err != nil {
+		log.Printf("[ERROR] Corrupt reconciliation report %d: %v", report.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "report is unreadable"})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="reconciliation-%d.csv"`, report.ID))
+	if err := reconcile.WriteCSV(c.Writer, entries); err != nil {
+		log.Printf("[ERROR] Failed to write reconciliation report %d: %v", report.ID, err)
+	}
+}
+
+func findReconciliation(c *gin.Context) (*models.ReconciliationReport, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report id"})
+		return nil, false
+	}
+	var report models.ReconciliationReport
+	if err := config.DB.First(&report, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return nil, false
+	}
+	return &report, true
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/models"
+	"github.com/bellapacxx/bingo-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// VoidGame cancels an unsettled game and refunds every paid card. A game
+// still running in a lobby is stopped first; one that paid a winner is
+// refused.
+func VoidGame(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid game id"})
+		return
+	}
+	gameID := uint(id)
+
+	var game models.Game
+	if err := config.DB.First(&game, gameID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+		return
+	}
+
+	var refunded int
+	if lobby := runningLobby(game.Stake, gameID); lobby != nil {
+		refunded, err = lobby.AbortRound(gameID, req.Reason)
+	} else {
+		refunded, err = services.VoidGame(gameID, req.Reason)
+	}
+	switch {
+	case errors.Is(err, services.ErrGameNotInProgress), errors.Is(err, services.ErrGameHasWinners),
+		errors.Is(err, services.ErrRoundNotRunning), errors.Is(err, services.ErrRoundSettling):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("[ERROR] Failed to void game %d: %v", gameID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to void game"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"gameId": gameID, "status": "voided", "refunded": refunded})
+}
+
+// runningLobby returns the lobby currently drawing for gameID, if any.
+func runningLobby(stake int, gameID uint) *services.Lobby {
+	services.LobbiesMu.Lock()
+	lobby := services.Lobbies[stake]
+	services.LobbiesMu.Unlock()
+	if lobby == nil {
+		return nil
+	}
+	if running, ok := lobby.CurrentGameID(); ok && running == gameID {
+		return lobby
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/models"
+	"github.com/bellapacxx/bingo-backend/payments"
+	"github.com/bellapacxx/bingo-backend/payments/sms"
+	"github.com/bellapacxx/bingo-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateDepositIntent records the amount a player is about to pay so a
+// forwarded SMS receipt can be matched to them
+func CreateDepositIntent(c *gin.Context) {
+	var req struct {
+		TelegramID int64        `json:"telegramId" binding:"required"`
+		Amount     models.Money `json:"amount" binding:"required"` // birr
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive"})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.Where("telegram_id = ?", req.TelegramID).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	intent, err := services.CreateDepositIntent(config.DB, user.ID, req.Amount)
+	if err != nil {
+		log.Printf("[ERROR] Failed to create deposit intent for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusCreated, intent)
+}
+
+// ConfirmSMSDeposit parses a forwarded payment SMS and credits it against the
+// player's pending deposit intent. Typed-in SMS can be forged, so the
+// transaction is confirmed with the receipt's provider and the provider's
+// amount and payer are what get recorded, with the same checks as
+// VerifyDeposit. Without a verifier for that provider the deposit is recorded
+// as disputed and left for an operator.
+func ConfirmSMSDeposit(c *gin.Context) {
+	var req struct {
+		TelegramID int64  `json:"telegramId" binding:"required"`
+		Text       string `json:"text" binding:"required"` // raw SMS body
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.Where("telegram_id = ?", req.TelegramID).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	receipt, err := sms.Parse(req.Text)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	deposit := models.Deposit{
+		UserID:         user.ID,
+		Amount:         receipt.Amount,
+		ExpectedAmount: receipt.Amount,
+		Reference:      receipt.TransactionID,
+		Provider:       "sms:" + receipt.Format,
+		PaidAt:         &receipt.Time,
+		Status:         models.DepositDisputed,
+		DisputeReason:  "payment could not be confirmed with the provider",
+	}
+	if verifier, err := payments.Lookup(receipt.Verifier); err == nil {
+		err = services.ConfirmDeposit(c.Request.Context(), verifier, &user, &deposit)
+		if errors.Is(err, payments.ErrReferenceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "payment not found with provider"})
+			return
+		}
+		if err != nil {
+			log.Printf("[ERROR] %s verification failed for %s: %v", verifier.Name(), receipt.TransactionID, err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "could not verify payment, try again later"})
+			return
+		}
+	}
+
+	intent, err := services.MatchDepositIntent(config.DB, &deposit)
+	switch {
+	case errors.Is(err, services.ErrNoMatchingIntent):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "receipt": receipt})
+		return
+	case errors.Is(err, services.ErrDuplicateDeposit):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case limitBlocked(c, user.ID, err), exclusionBlocked(c, err):
+		return
+	case err != nil:
+		log.Printf("[ERROR] Failed to record SMS deposit %s: %v", receipt.TransactionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update balance"})
+		return
+	}
+
+	if deposit.Status == models.DepositDisputed {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   deposit.DisputeReason + ", deposit is under review",
+			"deposit": deposit,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Balance updated successfully",
+		"amount":  deposit.Amount,
+		"intent":  intent,
+		"receipt": receipt,
+	})
+}
@@ -1,44 +1,39 @@
 package controllers
 
 import (
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/bellapacxx/bingo-backend/config"
 	"github.com/bellapacxx/bingo-backend/models"
+	"github.com/bellapacxx/bingo-backend/services"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// Deposit handles adding funds to user wallet
-func Deposit(c *gin.Context) {
-	var tx models.Transaction
-	if err := c.ShouldBindJSON(&tx); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	tx.Type = "deposit"
-	if err := config.DB.Create(&tx).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deposit"})
-		return
-	}
-
-	c.JSON(http.StatusCreated, tx)
-}
-
-// Withdraw handles user withdrawal
+// Withdraw opens a withdrawal request and holds the amount until it is
+// paid, rejected or cancelled
 func Withdraw(c *gin.Context) {
 	// Bind request JSON
 	var req struct {
-		TelegramID int64   `json:"telegramId"`
-		Amount     float64 `json:"amount"`
-		Method     string  `json:"method"`  // optional, for tracking method
-		Account    string  `json:"account"` // optional, for tracking account
+		TelegramID int64        `json:"telegramId"`
+		Amount     models.Money `json:"amount"`  // birr, up to two decimals
+		Method     string       `json:"method"`  // payout method, e.g. telebirr
+		Account    string       `json:"account"` // where to send the money
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be positive"})
+		return
+	}
 
 	// Find the user
 	var user models.User
@@ -47,41 +42,142 @@ func Withdraw(c *gin.Context) {
 		return
 	}
 
-	// Check if user has enough balance
-	if user.Balance < req.Amount {
+	// Hold the money in pending withdrawals until an operator pays it out
+	withdrawal, err := services.RequestWithdrawal(config.DB, user.ID, req.Amount, req.Method, req.Account)
+	if errors.Is(err, services.ErrInsufficientFunds) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient balance"})
 		return
 	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to withdraw for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to withdraw"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, withdrawal)
+}
+
+// ListUserTransactions returns a player's wallet history, newest first.
+//
+// Query parameters: type (comma separated), from and to (YYYY-MM-DD,
+// inclusive), limit (default 50, max 200) and cursor, the nextCursor of the
+// previous page. Totals cover every transaction matching the filters, not
+// just the page; credits, debits and net are cash, with the bonus wallet's
+// reported apart.
+func ListUserTransactions(c *gin.Context) {
+	tid, err := strconv.ParseInt(c.Param("telegram_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid telegram_id"})
+		return
+	}
 
-	// Start DB transaction for safety
-	tx := config.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+	var user models.User
+	if err := config.DB.Where("telegram_id = ?", tid).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+		limit = min(limit, 200)
+	}
+
+	q := config.DB.Model(&models.Transaction{}).Where("user_id = ?", user.ID)
+	if v := c.Query("type"); v != "" {
+		q = q.Where("type IN ?", strings.Split(v, ","))
+	}
+	for field, op := range map[string]string{"from": "created_at >= ?", "to": "created_at < ?"} {
+		v := c.Query(field)
+		if v == "" {
+			continue
+		}
+		day, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": field + " must be YYYY-MM-DD"})
+			return
 		}
-	}()
+		if field == "to" {
+			day = day.AddDate(0, 0, 1)
+		}
+		q = q.Where(op, day)
+	}
 
-	// Subtract balance
-	user.Balance -= req.Amount
-	if err := tx.Save(&user).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update balance"})
+	var totals []struct {
+		Type   models.TransactionType
+		Wallet models.AccountType
+		Count  int64
+		Sum    models.Money
+	}
+	if err := q.Session(&gorm.Session{}).
+		Select("type, wallet, COUNT(*) AS count, COALESCE(SUM(amount), 0) AS sum").
+		Group("type, wallet").Scan(&totals).Error; err != nil {
+		log.Printf("[ERROR] Failed to total transactions for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
 
-	// Create the transaction record
-	withdrawTx := models.Transaction{
-		UserID: uint(req.TelegramID),
-		Amount: req.Amount,
-		Type:   "withdraw",
+	page := q.Session(&gorm.Session{})
+	if v := c.Query("cursor"); v != "" {
+		cursor, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		page = page.Where("id < ?", cursor)
 	}
-	if err := tx.Create(&withdrawTx).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaction"})
+	var txs []models.Transaction
+	if err := page.Order("id DESC").Limit(limit + 1).Find(&txs).Error; err != nil {
+		log.Printf("[ERROR] Failed to fetch transactions for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
 
-	// Commit transaction
-	tx.Commit()
-	c.JSON(http.StatusCreated, withdrawTx)
+	var nextCursor *uint
+	if len(txs) > limit {
+		txs = txs[:limit]
+		nextCursor = &txs[limit-1].ID
+	}
+
+	// Credits and debits are per wallet: bonus money isn't cash
+	type sums struct {
+		count  int64
+		amount models.Money
+	}
+	byTypeSums := make(map[models.TransactionType]sums, len(totals))
+	credits := make(map[models.AccountType]models.Money, 2)
+	debits := make(map[models.AccountType]models.Money, 2)
+	for _, t := range totals {
+		s := byTypeSums[t.Type]
+		byTypeSums[t.Type] = sums{s.count + t.Count, s.amount + t.Sum}
+		if t.Type.IsDebit() {
+			debits[t.Wallet] += t.Sum
+		} else {
+			credits[t.Wallet] += t.Sum
+		}
+	}
+	byType := make(map[models.TransactionType]gin.H, len(byTypeSums))
+	for typ, s := range byTypeSums {
+		byType[typ] = gin.H{"count": s.count, "amount": s.amount}
+	}
+	cash, bonus := models.WalletAccount, models.BonusWalletAccount
+
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": txs,
+		"nextCursor":   nextCursor,
+		"totals": gin.H{
+			"byType":  byType,
+			"credits": credits[cash],
+			"debits":  debits[cash],
+			"net":     credits[cash] - debits[cash],
+			"bonus": gin.H{
+				"credits": credits[bonus],
+				"debits":  debits[bonus],
+				"net":     credits[bonus] - debits[bonus],
+			},
+		},
+	})
 }
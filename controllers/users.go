@@ -4,21 +4,35 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/bellapacxx/bingo-backend/config"
 	"github.com/bellapacxx/bingo-backend/models"
+	"github.com/bellapacxx/bingo-backend/services"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 // RegisterUser registers a new user (from Telegram)
 // RegisterUser registers a Telegram user or returns existing
+// A start_param from the bot's deep link is recorded as a referral
 func RegisterUser(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	var req struct {
+		models.User
+		StartParam string `json:"start_param"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	user := req.User
+	// Money and codes are never taken from the client, and the phone only
+	// through UpdatePhone, which checks no other account has it
+	user.ID = 0
+	user.ReferralCode = nil
+	user.Balance = 0
+	user.BonusBalance = 0
+	user.Phone = ""
 
 	if user.TelegramID == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "telegram_id is required"})
@@ -38,7 +52,17 @@ func RegisterUser(c *gin.Context) {
 	}
 
 	// Create new user
-	if err := config.DB.Create(&user).Error; err != nil {
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		if _, err := services.EnsureReferralCode(tx, &user); err != nil {
+			return err
+		}
+		_, err := services.AttributeReferral(tx, &user, req.StartParam)
+		return err
+	})
+	if err != nil {
 		log.Printf("[ERROR] Failed to create user: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
@@ -70,7 +94,7 @@ func GetUser(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
-// UpdatePhone updates a user's phone number
+// UpdatePhone updates a user's phone number, stored as its national part
 func UpdatePhone(c *gin.Context) {
 	tidStr := c.Param("telegram_id")
 	tid, err := strconv.ParseInt(tidStr, 10, 64)
@@ -99,8 +123,26 @@ func UpdatePhone(c *gin.Context) {
 		return
 	}
 
+	phone := services.NormalizePhone(req.Phone)
+	if phone == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+		return
+	}
+
+	// One phone number per account, so referral rewards can't be farmed
+	taken, err := services.PhoneTaken(config.DB, phone, user.ID)
+	if err != nil {
+		log.Printf("[ERROR] Failed to check phone for telegram_id %d: %v", tid, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if taken {
+		c.JSON(http.StatusConflict, gin.H{"error": "Phone number is already used by another account"})
+		return
+	}
+
 	// Update phone
-	if err := config.DB.Model(&user).Update("phone", req.Phone).Error; err != nil {
+	if err := config.DB.Model(&user).Update("phone", phone).Error; err != nil {
 		log.Printf("[ERROR] Failed to update phone for telegram_id %d: %v", tid, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update phone"})
 		return
@@ -108,6 +150,60 @@ func UpdatePhone(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"telegram_id": tid,
-		"phone":       req.Phone,
+		"phone":       phone,
 	})
 }
+
+// GetReferrals returns the player's invite link and referral stats
+func GetReferrals(c *gin.Context) {
+	user, ok := userFromParam(c)
+	if !ok {
+		return
+	}
+	stats, err := services.UserReferralStats(config.DB, user)
+	if err != nil {
+		log.Printf("[ERROR] Failed to fetch referral stats for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	var referrals []struct {
+		Name      string                `json:"name"`
+		Status    models.ReferralStatus `json:"status"`
+		Reward    models.Money          `json:"reward"`
+		CreatedAt time.Time             `json:"createdAt"`
+	}
+	if err := config.DB.Table("referrals").
+		Select("users.name, referrals.status, referrals.reward, referrals.created_at").
+		Joins("JOIN users ON users.id = referrals.referred_id").
+		Where("referrals.referrer_id = ?", user.ID).
+		Order("referrals.id DESC").Limit(100).
+		Scan(&referrals).Error; err != nil {
+		log.Printf("[ERROR] Failed to fetch referrals for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"stats": stats, "referrals": referrals})
+}
+
+// AdminListReferrals lists referrals, optionally by status, with both players
+func AdminListReferrals(c *gin.Context) {
+	var rows []struct {
+		models.Referral
+		ReferrerTelegramID int64 `json:"referrerTelegramId"`
+		ReferredTelegramID int64 `json:"referredTelegramId"`
+	}
+	q := config.DB.Table("referrals").
+		Select("referrals.*, a.telegram_id AS referrer_telegram_id, b.telegram_id AS referred_telegram_id").
+		Joins("JOIN users a ON a.id = referrals.referrer_id").
+		Joins("JOIN users b ON b.id = referrals.referred_id")
+	if status := c.Query("status"); status != "" {
+		q = q.Where("referrals.status = ?", status)
+	}
+	if err := q.Order("referrals.id DESC").Limit(500).Scan(&rows).Error; err != nil {
+		log.Printf("[ERROR] Failed to fetch referrals: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
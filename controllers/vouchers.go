@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/middleware"
+	"github.com/bellapacxx/bingo-backend/models"
+	"github.com/bellapacxx/bingo-backend/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RedeemVoucher credits a scratch voucher to the player's wallet
+func RedeemVoucher(c *gin.Context) {
+	var req struct {
+		UserID int64  `json:"userId" binding:"required"` // Telegram ID
+		Code   string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.Where("telegram_id = ?", req.UserID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		log.Printf("[ERROR] Failed to fetch user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	deposit, err := services.RedeemVoucher(config.DB, user.ID, req.Code, c.ClientIP())
+	switch {
+	case errors.Is(err, services.ErrVoucherLocked):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrVoucherInvalid):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrVoucherExpired), errors.Is(err, services.ErrVoucherUsedUp), errors.Is(err, services.ErrVoucherRepeated):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if limitBlocked(c, user.ID, err) || exclusionBlocked(c, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to redeem voucher for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update balance"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Voucher redeemed",
+		"amount":  deposit.Amount,
+	})
+}
+
+// CreateVoucherBatch generates a batch of voucher codes
+func CreateVoucherBatch(c *gin.Context) {
+	var req struct {
+		Name      string       `json:"name"`
+		Amount    models.Money `json:"amount" binding:"required"` // per code, in birr
+		Count     int          `json:"count" binding:"required"`
+		MaxUses   int          `json:"maxUses"` // per code, default 1
+		ExpiresAt time.Time    `json:"expiresAt" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.MaxUses == 0 {
+		req.MaxUses = 1
+	}
+
+	batch, err := services.CreateVoucherBatch(services.VoucherBatchRequest{
+		Name:      req.Name,
+		Amount:    req.Amount,
+		Count:     req.Count,
+		MaxUses:   req.MaxUses,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: middleware.AdminUser(c),
+	})
+	if errors.Is(err, services.ErrInvalidVoucherBatch) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to create voucher batch: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusCreated, batch)
+}
+
+// ListVoucherBatches lists batches with how many redemptions each has had
+func ListVoucherBatches(c *gin.Context) {
+	var rows []struct {
+		models.VoucherBatch
+		Redeemed int64 `json:"redeemed"`
+	}
+	if err := config.DB.Table("voucher_batches").
+		Select("voucher_batches.*, COALESCE((SELECT SUM(v.uses) FROM vouchers v WHERE v.batch_id = voucher_batches.id), 0) AS redeemed").
+		Order("voucher_batches.id DESC").
+		Scan(&rows).Error; err != nil {
+		log.Printf("[ERROR] Failed to fetch voucher batches: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// ExportVoucherBatch downloads a batch's codes as CSV for printing
+func ExportVoucherBatch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid batch id"})
+		return
+	}
+	var batch models.VoucherBatch
+	if err := config.DB.First(&batch, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Batch not found"})
+		return
+	}
+
+	log.Printf("[Voucher] batch %d exported by %s", batch.ID, middleware.AdminUser(c))
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="vouchers-%d.csv"`, batch.ID))
+	if err := services.WriteVoucherCSV(c.Writer, batch.ID); err != nil {
+		log.Printf("[ERROR] Failed to write voucher batch %d: %v", batch.ID, err)
+	}
+}
+
+// ListVoucherAttempts returns the redemption audit trail, newest first,
+// filtered by result, user or code
+func ListVoucherAttempts(c *gin.Context) {
+	q := config.DB.Order("id DESC").Limit(500)
+	if result := c.Query("result"); result != "" {
+		q = q.Where("result = ?", result)
+	}
+	if userID := c.Query("userId"); userID != "" {
+		q = q.Where("user_id = ?", userID)
+	}
+	if code := c.Query("code"); code != "" {
+		q = q.Where("code = ?", services.NormalizeVoucherCode(code))
+	}
+
+	var attempts []models.VoucherAttempt
+	if err := q.Find(&attempts).Error; err != nil {
+		log.Printf("[ERROR] Failed to fetch voucher attempts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, attempts)
+}
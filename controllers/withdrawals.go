@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/middleware"
+	"github.com/bellapacxx/bingo-backend/models"
+	"github.com/bellapacxx/bingo-backend/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListUserWithdrawals returns a player's withdrawal requests, newest first
+func ListUserWithdrawals(c *gin.Context) {
+	tid, err := strconv.ParseInt(c.Param("telegram_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid telegram_id"})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.Where("telegram_id = ?", tid).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var withdrawals []models.WithdrawalRequest
+	if err := config.DB.Where("user_id = ?", user.ID).Order("id DESC").Find(&withdrawals).Error; err != nil {
+		log.Printf("[ERROR] Failed to fetch withdrawals for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, withdrawals)
+}
+
+// CancelWithdrawal lets a player take back a request that hasn't been reviewed
+func CancelWithdrawal(c *gin.Context) {
+	var req struct {
+		TelegramID int64 `json:"telegramId" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.Where("telegram_id = ?", req.TelegramID).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	withdrawal, ok := findWithdrawal(c)
+	if !ok {
+		return
+	}
+	if withdrawal.UserID != user.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Withdrawal not found"})
+		return
+	}
+
+	transitionWithdrawal(c, withdrawal.ID, models.WithdrawalCancelled, services.WithdrawalUpdate{})
+}
+
+// AdminListWithdrawals lists withdrawal requests, optionally by status
+func AdminListWithdrawals(c *gin.Context) {
+	q := config.DB.Order("id DESC")
+	if status := c.Query("status"); status != "" {
+		q = q.Where("status = ?", status)
+	}
+
+	var withdrawals []models.WithdrawalRequest
+	if err := q.Find(&withdrawals).Error; err != nil {
+		log.Printf("[ERROR] Failed to fetch withdrawals: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, withdrawals)
+}
+
+// ApproveWithdrawal clears a request for payout
+func ApproveWithdrawal(c *gin.Context) {
+	withdrawal, ok := findWithdrawal(c)
+	if !ok {
+		return
+	}
+	transitionWithdrawal(c, withdrawal.ID, models.WithdrawalApproved, services.WithdrawalUpdate{
+		ReviewedBy: middleware.AdminUser(c),
+	})
+}
+
+// RejectWithdrawal refuses a request and returns the funds to the player
+func RejectWithdrawal(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	withdrawal, ok := findWithdrawal(c)
+	if !ok {
+		return
+	}
+	transitionWithdrawal(c, withdrawal.ID, models.WithdrawalRejected, services.WithdrawalUpdate{
+		Reason:     req.Reason,
+		ReviewedBy: middleware.AdminUser(c),
+	})
+}
+
+// MarkWithdrawalPaid records that an approved request was sent to the player
+func MarkWithdrawalPaid(c *gin.Context) {
+	var req struct {
+		PayoutReference string `json:"payoutReference" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	withdrawal, ok := findWithdrawal(c)
+	if !ok {
+		return
+	}
+	transitionWithdrawal(c, withdrawal.ID, models.WithdrawalPaid, services.WithdrawalUpdate{
+		PayoutReference: req.PayoutReference,
+		ReviewedBy:      middleware.AdminUser(c),
+	})
+}
+
+func findWithdrawal(c *gin.Context) (*models.WithdrawalRequest, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid withdrawal id"})
+		return nil, false
+	}
+
+	var withdrawal models.WithdrawalRequest
+	if err := config.DB.First(&withdrawal, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Withdrawal not found"})
+			return nil, false
+		}
+		log.Printf("[ERROR] Failed to fetch withdrawal %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+	return &withdrawal, true
+}
+
+func transitionWithdrawal(c *gin.Context, id uint, to models.WithdrawalStatus, upd services.WithdrawalUpdate) {
+	withdrawal, err := services.TransitionWithdrawal(config.DB, id, to, upd)
+	if errors.Is(err, services.ErrInvalidTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to move withdrawal %d to %s: %v", id, to, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update withdrawal"})
+		return
+	}
+	c.JSON(http.StatusOK, withdrawal)
+}
@@ -0,0 +1,95 @@
+package game
+
+// Size is the width and height of a bingo card.
+const Size = 5
+
+// Card is a 5x5 bingo card, indexed [row][col]. Columns are B, I, N, G, O;
+// the centre square is free whatever number it holds.
+type Card [Size][Size]int
+
+// NewCard builds a card from its numbers listed column by column (all of
+// B, then I, N, G and O), the order cards.json uses.
+func NewCard(numbers []int) (Card, bool) {
+	var c Card
+	if len(numbers) != Size*Size {
+		return c, false
+	}
+	for col := 0; col < Size; col++ {
+		for row := 0; row < Size; row++ {
+			c[row][col] = numbers[col*Size+row]
+		}
+	}
+	return c, true
+}
+
+// Numbers lists the card column by column, the inverse of NewCard.
+func (c Card) Numbers() []int {
+	out := make([]int, 0, Size*Size)
+	for col := 0; col < Size; col++ {
+		for row := 0; row < Size; row++ {
+			out = append(out, c[row][col])
+		}
+	}
+	return out
+}
+
+func isFree(row, col int) bool { return row == Size/2 && col == Size/2 }
+
+// Covered returns the squares covered by drawn, free centre included.
+func (c Card) Covered(drawn map[int]bool) Mask {
+	var m Mask
+	for row := 0; row < Size; row++ {
+		for col := 0; col < Size; col++ {
+			if isFree(row, col) || drawn[c[row][col]] {
+				m |= bit(row, col)
+			}
+		}
+	}
+	return m
+}
+
+// Match returns the first of patterns the drawn numbers complete, and the
+// mask of it that is covered.
+func (c Card) Match(drawn map[int]bool, patterns []Pattern) (Pattern, Mask, bool) {
+	covered := c.Covered(drawn)
+	for _, p := range patterns {
+		for _, m := range p.Masks {
+			if covered&m == m {
+				return p, m, true
+			}
+		}
+	}
+	return Pattern{}, 0, false
+}
+
+// HasBingo reports whether the drawn numbers complete any Classic pattern.
+func (c Card) HasBingo(drawn map[int]bool) bool {
+	_, _, ok := c.Match(drawn, Classic)
+	return ok
+}
+
+// Cell is one square of a card.
+type Cell struct {
+	Row    int  `json:"row"`
+	Col    int  `json:"col"`
+	Number int  `json:"number"`
+	Free   bool `json:"free,omitempty"`
+}
+
+// Cells lists the squares of m, row by row.
+func (c Card) Cells(m Mask) []Cell {
+	var out []Cell
+	for row := 0; row < Size; row++ {
+		for col := 0; col < Size; col++ {
+			if m&bit(row, col) != 0 {
+				out = append(out, Cell{Row: row, Col: col, Number: c[row][col], Free: isFree(row, col)})
+			}
+		}
+	}
+	return out
+}
+
+// FullCard reports whether every square is covered.
+func (c Card) FullCard(drawn map[int]bool) bool {
+	return c.Covered(drawn) == FullMask
+}
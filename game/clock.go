@@ -0,0 +1,27 @@
+package game
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Clock tells the engine the time. Tests pass a clock they move by hand.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the wall clock.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// RNG shuffles the balls for a round. *math/rand.Rand satisfies it, so a
+// seeded source gives a reproducible draw.
+type RNG interface {
+	Shuffle(n int, swap func(i, j int))
+}
+
+// NewRNG returns an RNG seeded from the wall clock.
+func NewRNG() RNG {
+	return rand.New(rand.NewSource(time.Now().UnixNano()))
+}
@@ -0,0 +1,486 @@
+package game
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// Phase is where a round is in its life cycle:
+// waiting → countdown → drawing → claiming → settled → waiting.
+type Phase string
+
+const (
+	Waiting   Phase = "waiting"   // no round open
+	Countdown Phase = "countdown" // players pick cards
+	Drawing   Phase = "drawing"   // balls are drawn, claims accepted
+	Claiming  Phase = "claiming"  // a bingo was claimed; no more balls, the claim window is open
+	Settled   Phase = "settled"   // winners decided or round voided; shown until cleared
+)
+
+var (
+	ErrWrongPhase     = errors.New("not allowed in this phase of the round")
+	ErrCardTaken      = errors.New("card is already taken")
+	ErrTooManyCards   = errors.New("player holds the most cards allowed")
+	ErrNoCard         = errors.New("player has no such card")
+	ErrAlreadyClaimed = errors.New("card was already checked this round")
+	ErrNoBingo        = errors.New("card has no bingo")
+	ErrClaimsClosed   = errors.New("claims are closed for this round")
+	ErrHasWinners     = errors.New("round already has a winner")
+)
+
+// Config holds the timings that are the same for every round.
+type Config struct {
+	Countdown    time.Duration
+	DrawInterval time.Duration // between balls, and before the first
+	ResultsDelay time.Duration // how long a settled round is shown
+	MinCards     int           // fewer cards at the end of the countdown restart it
+	Balls        int           // numbered 1..Balls
+}
+
+// Rules are the per-round settings, fixed when the countdown opens.
+type Rules struct {
+	ClaimWindow     time.Duration
+	JackpotMaxBalls int // a full card within this many balls qualifies; 0 turns it off
+	MaxCards        int // cards one player can hold; 0 means 1
+	// Patterns a claim can win with, in the order they are tried. None
+	// means Classic.
+	Patterns []Pattern
+	// Draw shuffles this round's balls, e.g. a *FairDraw; nil uses the
+	// engine's RNG.
+	Draw RNG
+}
+
+func (r Rules) maxCards() int {
+	return max(r.MaxCards, 1)
+}
+
+func (r Rules) patterns() []Pattern {
+	if len(r.Patterns) == 0 {
+		return Classic
+	}
+	return r.Patterns
+}
+
+// Winner is one valid claim. A player with several winning cards is a
+// winner once per card.
+type Winner struct {
+	Player   uint
+	CardID   int
+	Pattern  Pattern // what the card won with
+	Cells    []Cell  // the squares of the pattern that won
+	Marked   Mask    // every covered square of the card at the claim
+	Ball     int     // the drawn ball that completed the pattern
+	Balls    int     // balls drawn when the claim was made
+	FullCard bool    // every square covered, within Rules.JackpotMaxBalls
+}
+
+// Selection is one of a player's cards for the round.
+type Selection struct {
+	CardID int
+	Card   Card
+}
+
+// Engine runs the rounds of one lobby. It does no I/O and keeps no
+// goroutines: time only moves when Tick is called, and the clock and RNG
+// are injected, so a whole round can be played out in a test. It is not
+// safe for concurrent use.
+type Engine struct {
+	cfg   Config
+	clock Clock
+	rng   RNG
+
+	phase    Phase
+	rules    Rules
+	deadline time.Time // when the current phase's timer fires
+	shown    int       // countdown seconds last reported
+
+	cards   map[uint][]Selection // player -> cards, in the order they were taken
+	taken   map[int]uint         // cardID -> player
+	balls   []int                // the round's draw order
+	drawn   []int
+	marked  map[int]bool
+	claimed map[int]bool // cardIDs already checked
+	winners []Winner
+	voided  bool
+}
+
+// New returns an engine in the waiting phase.
+func New(cfg Config, clock Clock, rng RNG) *Engine {
+	e := &Engine{cfg: cfg, clock: clock, rng: rng}
+	e.reset()
+	return e
+}
+
+func (e *Engine) reset() {
+	e.phase = Waiting
+	e.rules = Rules{}
+	e.deadline = time.Time{}
+	e.cards = make(map[uint][]Selection)
+	e.taken = make(map[int]uint)
+	e.balls = nil
+	e.drawn = nil
+	e.marked = make(map[int]bool)
+	e.claimed = make(map[int]bool)
+	e.winners = nil
+	e.voided = false
+}
+
+func (e *Engine) enter(to Phase) Event {
+	from := e.phase
+	e.phase = to
+	return PhaseChanged{From: from, To: to}
+}
+
+// Phase returns the current phase.
+func (e *Engine) Phase() Phase { return e.phase }
+
+// CanSelect reports whether cards can be picked or given up.
+func (e *Engine) CanSelect() bool {
+	return e.phase == Waiting || e.phase == Countdown
+}
+
+// Taken reports whether a card belongs to a player this round.
+func (e *Engine) Taken(cardID int) bool {
+	_, ok := e.taken[cardID]
+	return ok
+}
+
+// Selections returns the player's cards.
+func (e *Engine) Selections(player uint) []Selection {
+	return append([]Selection(nil), e.cards[player]...)
+}
+
+// MaxCards is how many cards one player can hold this round.
+func (e *Engine) MaxCards() int { return e.rules.maxCards() }
+
+// OpenCountdown starts the countdown to the next round under rules.
+func (e *Engine) OpenCountdown(rules Rules) ([]Event, error) {
+	if e.phase != Waiting {
+		return nil, ErrWrongPhase
+	}
+	e.rules = rules
+	e.deadline = e.clock.Now().Add(e.cfg.Countdown)
+	e.shown = e.countdownLeft()
+	return []Event{e.enter(Countdown), CountdownTick{Remaining: e.shown}}, nil
+}
+
+// Select adds card to the player's cards, up to Rules.MaxCards.
+func (e *Engine) Select(player uint, cardID int, card Card) ([]Event, error) {
+	if err := e.checkFree(cardID); err != nil {
+		return nil, err
+	}
+	if len(e.cards[player]) >= e.rules.maxCards() {
+		return nil, ErrTooManyCards
+	}
+	e.cards[player] = append(e.cards[player], Selection{CardID: cardID, Card: card})
+	e.taken[cardID] = player
+	return []Event{CardSelected{Player: player, CardID: cardID}}, nil
+}
+
+// Swap replaces one of the player's cards with another.
+func (e *Engine) Swap(player uint, previous, cardID int, card Card) ([]Event, error) {
+	if err := e.checkFree(cardID); err != nil {
+		return nil, err
+	}
+	i := e.index(player, previous)
+	if i < 0 {
+		return nil, ErrNoCard
+	}
+	e.cards[player][i] = Selection{CardID: cardID, Card: card}
+	delete(e.taken, previous)
+	e.taken[cardID] = player
+	return []Event{CardSelected{Player: player, CardID: cardID, Previous: previous}}, nil
+}
+
+func (e *Engine) checkFree(cardID int) error {
+	if !e.CanSelect() {
+		return ErrWrongPhase
+	}
+	if _, ok := e.taken[cardID]; ok {
+		return ErrCardTaken
+	}
+	return nil
+}
+
+// Deselect gives one of the player's cards up before the round starts.
+func (e *Engine) Deselect(player uint, cardID int) ([]Event, error) {
+	if !e.CanSelect() {
+		return nil, ErrWrongPhase
+	}
+	return e.release(player, cardID)
+}
+
+// DeselectAll gives every card of the player up before the round starts.
+func (e *Engine) DeselectAll(player uint) ([]Event, error) {
+	if !e.CanSelect() {
+		return nil, ErrWrongPhase
+	}
+	if len(e.cards[player]) == 0 {
+		return nil, ErrNoCard
+	}
+	var events []Event
+	for _, sel := range e.Selections(player) {
+		ev, _ := e.release(player, sel.CardID)
+		events = append(events, ev...)
+	}
+	return events, nil
+}
+
+// Remove takes one of a player's cards out of a round that is drawing,
+// e.g. when its stake could not be taken. Winners can't be removed.
+func (e *Engine) Remove(player uint, cardID int) ([]Event, error) {
+	if e.phase != Countdown && e.phase != Drawing {
+		return nil, ErrWrongPhase
+	}
+	return e.release(player, cardID)
+}
+
+func (e *Engine) release(player uint, cardID int) ([]Event, error) {
+	i := e.index(player, cardID)
+	if i < 0 {
+		return nil, ErrNoCard
+	}
+	e.cards[player] = append(e.cards[player][:i], e.cards[player][i+1:]...)
+	if len(e.cards[player]) == 0 {
+		delete(e.cards, player)
+	}
+	delete(e.taken, cardID)
+	return []Event{CardReleased{Player: player, CardID: cardID}}, nil
+}
+
+// index returns the position of cardID among the player's cards, or -1.
+func (e *Engine) index(player uint, cardID int) int {
+	for i, sel := range e.cards[player] {
+		if sel.CardID == cardID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Claim checks the player's bingo on cardID, or on every card they haven't
+// checked yet when cardID is 0. Each card gets one check per round; a
+// failed check uses it up. The first valid claim stops the draw and opens
+// the claim window, so every claim in it is judged on the same balls.
+func (e *Engine) Claim(player uint, cardID int) ([]Event, error) {
+	switch {
+	case e.phase == Claiming && !e.clock.Now().Before(e.deadline),
+		e.phase == Settled && !e.voided:
+		return nil, ErrClaimsClosed
+	case e.phase != Drawing && e.phase != Claiming:
+		return nil, ErrWrongPhase
+	}
+
+	var check []Selection
+	for _, sel := range e.cards[player] {
+		if (cardID == 0 || sel.CardID == cardID) && !e.claimed[sel.CardID] {
+			check = append(check, sel)
+		}
+	}
+	if len(check) == 0 {
+		if cardID == 0 && len(e.cards[player]) > 0 || e.index(player, cardID) >= 0 {
+			return nil, ErrAlreadyClaimed
+		}
+		return nil, ErrNoCard
+	}
+
+	var events []Event
+	won := false
+	for _, sel := range check {
+		e.claimed[sel.CardID] = true
+		ev, ok := e.judge(player, sel)
+		events = append(events, ev...)
+		won = won || ok
+	}
+	if !won {
+		return events, ErrNoBingo
+	}
+	return events, nil
+}
+
+// judge checks one card and records it as a winner if it has a pattern.
+func (e *Engine) judge(player uint, sel Selection) ([]Event, bool) {
+	pattern, mask, ok := sel.Card.Match(e.marked, e.rules.patterns())
+	if !ok {
+		return []Event{ClaimRejected{Player: player, CardID: sel.CardID}}, false
+	}
+
+	cells := sel.Card.Cells(mask)
+	w := Winner{
+		Player:  player,
+		CardID:  sel.CardID,
+		Pattern: pattern,
+		Cells:   cells,
+		Marked:  sel.Card.Covered(e.marked),
+		Ball:    e.completedBy(cells),
+		Balls:   len(e.drawn),
+	}
+	w.FullCard = e.rules.JackpotMaxBalls > 0 && w.Balls <= e.rules.JackpotMaxBalls && sel.Card.FullCard(e.marked)
+	e.winners = append(e.winners, w)
+	first := len(e.winners) == 1
+	events := []Event{ClaimAccepted{Winner: w, First: first}}
+	if first {
+		e.deadline = e.clock.Now().Add(e.rules.ClaimWindow)
+		events = append(events, e.enter(Claiming))
+	}
+	return events, true
+}
+
+// completedBy returns the last drawn of the cells' numbers, the ball that
+// completed them.
+func (e *Engine) completedBy(cells []Cell) int {
+	for i := len(e.drawn) - 1; i >= 0; i-- {
+		for _, c := range cells {
+			if !c.Free && c.Number == e.drawn[i] {
+				return e.drawn[i]
+			}
+		}
+	}
+	return 0
+}
+
+// Void calls off a drawing round. A round that already has a winner is
+// being paid and can't be voided.
+func (e *Engine) Void(reason string) ([]Event, error) {
+	if len(e.winners) > 0 {
+		return nil, ErrHasWinners
+	}
+	if e.phase != Drawing {
+		return nil, ErrWrongPhase
+	}
+	e.voided = true
+	e.deadline = e.clock.Now().Add(e.cfg.ResultsDelay)
+	return []Event{e.enter(Settled), RoundVoided{Reason: reason}}, nil
+}
+
+// Tick moves the round along to the clock's current time: it counts down,
+// starts the round, draws a ball when one is due, closes the claim window
+// and clears a settled round.
+func (e *Engine) Tick() []Event {
+	now := e.clock.Now()
+	switch e.phase {
+	case Countdown:
+		if now.Before(e.deadline) {
+			if left := e.countdownLeft(); left != e.shown {
+				e.shown = left
+				return []Event{CountdownTick{Remaining: left}}
+			}
+			return nil
+		}
+		if len(e.taken) < max(e.cfg.MinCards, 1) {
+			e.rules = Rules{}
+			return []Event{e.enter(Waiting)}
+		}
+		return e.start(now)
+
+	case Drawing:
+		if now.Before(e.deadline) {
+			return nil
+		}
+		if len(e.drawn) == len(e.balls) {
+			// The last ball had its claim window and nobody won
+			return e.settle(now)
+		}
+		ball := e.balls[len(e.drawn)]
+		e.drawn = append(e.drawn, ball)
+		e.marked[ball] = true
+		if len(e.drawn) == len(e.balls) {
+			e.deadline = now.Add(e.rules.ClaimWindow)
+		} else {
+			e.deadline = now.Add(e.cfg.DrawInterval)
+		}
+		return []Event{BallDrawn{Ball: ball, Count: len(e.drawn)}}
+
+	case Claiming:
+		if now.Before(e.deadline) {
+			return nil
+		}
+		return e.settle(now)
+
+	case Settled:
+		if now.Before(e.deadline) {
+			return nil
+		}
+		ended := RoundEnded{Voided: e.voided, Winners: e.winners}
+		e.reset()
+		return []Event{ended, PhaseChanged{From: Settled, To: Waiting}}
+	}
+	return nil
+}
+
+func (e *Engine) start(now time.Time) []Event {
+	e.balls = make([]int, e.cfg.Balls)
+	for i := range e.balls {
+		e.balls[i] = i + 1
+	}
+	rng := e.rules.Draw
+	if rng == nil {
+		rng = e.rng
+	}
+	rng.Shuffle(len(e.balls), func(i, j int) { e.balls[i], e.balls[j] = e.balls[j], e.balls[i] })
+	e.deadline = now.Add(e.cfg.DrawInterval)
+
+	cards := make(map[int]uint, len(e.taken))
+	for cardID, player := range e.taken {
+		cards[cardID] = player
+	}
+	return []Event{e.enter(Drawing), RoundStarted{Cards: cards}}
+}
+
+func (e *Engine) settle(now time.Time) []Event {
+	e.deadline = now.Add(e.cfg.ResultsDelay)
+	return []Event{e.enter(Settled), ClaimsClosed{Winners: append([]Winner(nil), e.winners...)}}
+}
+
+func (e *Engine) countdownLeft() int {
+	left := e.deadline.Sub(e.clock.Now()).Seconds()
+	return int(math.Max(0, math.Ceil(left)))
+}
+
+// State is a copy of the round for display.
+type State struct {
+	Phase     Phase
+	Countdown int       // seconds left, or the full countdown outside it
+	Patterns  []Pattern // what wins this round; empty while waiting
+	Drawn     []int
+	Cards     map[uint][]Selection
+	Taken     []int        // card IDs in use, sorted
+	Checked   map[int]bool // cards already checked for bingo
+	MaxCards  int          // cards one player can hold
+	Winners   []Winner
+	Voided    bool
+}
+
+// Snapshot returns the current state. It shares nothing with the engine.
+func (e *Engine) Snapshot() State {
+	s := State{
+		Phase:     e.phase,
+		Countdown: int(e.cfg.Countdown / time.Second),
+		Drawn:     append([]int(nil), e.drawn...),
+		Cards:     make(map[uint][]Selection, len(e.cards)),
+		Taken:     make([]int, 0, len(e.taken)),
+		Checked:   make(map[int]bool, len(e.claimed)),
+		MaxCards:  e.rules.maxCards(),
+		Winners:   append([]Winner(nil), e.winners...),
+		Voided:    e.voided,
+	}
+	if e.phase != Waiting {
+		s.Patterns = append([]Pattern(nil), e.rules.patterns()...)
+	}
+	if e.phase == Countdown {
+		s.Countdown = e.countdownLeft()
+	}
+	for player := range e.cards {
+		s.Cards[player] = e.Selections(player)
+	}
+	for cardID := range e.claimed {
+		s.Checked[cardID] = true
+	}
+	for cardID := range e.taken {
+		s.Taken = append(s.Taken, cardID)
+	}
+	sort.Ints(s.Taken)
+	return s
+}
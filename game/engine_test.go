@@ -0,0 +1,387 @@
+package game
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testConfig = Config{
+	Countdown:    3 * time.Second,
+	DrawInterval: time.Second,
+	ResultsDelay: 5 * time.Second,
+	MinCards:     1,
+	Balls:        75,
+}
+
+const testClaimWindow = 2 * time.Second
+
+// testCard has B 1-5, I 16-20, N 31-35, G 46-50 and O 61-65 from top to
+// bottom, so row 0 is 1 16 31 46 61 and the free centre holds 33.
+var testCard = cardFrom(1)
+
+// otherCard shares no number with testCard.
+var otherCard = cardFrom(6)
+
+func cardFrom(first int) Card {
+	var c Card
+	for row := 0; row < Size; row++ {
+		for col := 0; col < Size; col++ {
+			c[row][col] = col*15 + row + first
+		}
+	}
+	return c
+}
+
+type fakeClock struct{ now time.Time }
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 12, 14, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// orderRNG draws the given balls first and the rest in ascending order.
+type orderRNG []int
+
+func (r orderRNG) Shuffle(n int, swap func(i, j int)) {
+	pos := make([]int, n+1) // ball -> position
+	at := make([]int, n)    // position -> ball
+	for i := range at {
+		at[i], pos[i+1] = i+1, i
+	}
+	for i, ball := range r {
+		j := pos[ball]
+		swap(i, j)
+		at[i], at[j] = at[j], at[i]
+		pos[at[i]], pos[at[j]] = i, j
+	}
+}
+
+func mustDo(t *testing.T) func([]Event, error) []Event {
+	return func(events []Event, err error) []Event {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return events
+	}
+}
+
+func expectEvents(t *testing.T, step string, got []Event, want ...Event) {
+	t.Helper()
+	if len(got) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("%s:\n got %#v\nwant %#v", step, got, want)
+	}
+}
+
+func expectErr(t *testing.T, step string, err, want error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Fatalf("%s: err = %v, want %v", step, err, want)
+	}
+}
+
+// drawBalls moves the clock one draw interval at a time and checks each
+// ball comes out in order.
+func drawBalls(t *testing.T, e *Engine, clock *fakeClock, balls ...int) {
+	t.Helper()
+	for _, ball := range balls {
+		count := len(e.Snapshot().Drawn) + 1
+		clock.advance(testConfig.DrawInterval)
+		expectEvents(t, "draw", e.Tick(), BallDrawn{Ball: ball, Count: count})
+	}
+}
+
+func TestRoundLifecycle(t *testing.T) {
+	clock := newFakeClock()
+	e := New(testConfig, clock, orderRNG{61, 16, 2, 31, 46, 1})
+
+	expectEvents(t, "open", mustDo(t)(e.OpenCountdown(Rules{ClaimWindow: testClaimWindow})),
+		PhaseChanged{From: Waiting, To: Countdown}, CountdownTick{Remaining: 3})
+	expectEvents(t, "select", mustDo(t)(e.Select(7, 1, testCard)), CardSelected{Player: 7, CardID: 1})
+	expectEvents(t, "select", mustDo(t)(e.Select(8, 2, otherCard)), CardSelected{Player: 8, CardID: 2})
+	_, err := e.Select(9, 1, otherCard)
+	expectErr(t, "select a taken card", err, ErrCardTaken)
+
+	clock.advance(time.Second)
+	expectEvents(t, "countdown", e.Tick(), CountdownTick{Remaining: 2})
+	clock.advance(500 * time.Millisecond)
+	expectEvents(t, "countdown within the same second", e.Tick())
+	clock.advance(1500 * time.Millisecond)
+	expectEvents(t, "start", e.Tick(),
+		PhaseChanged{From: Countdown, To: Drawing}, RoundStarted{Cards: map[int]uint{1: 7, 2: 8}})
+	_, err = e.Select(9, 3, otherCard)
+	expectErr(t, "select while drawing", err, ErrWrongPhase)
+
+	drawBalls(t, e, clock, 61, 16, 2, 31, 46)
+	events, err := e.Claim(8, 2)
+	expectErr(t, "claim without bingo", err, ErrNoBingo)
+	expectEvents(t, "claim without bingo", events, ClaimRejected{Player: 8, CardID: 2})
+	_, err = e.Claim(8, 2)
+	expectErr(t, "second claim on a card", err, ErrAlreadyClaimed)
+
+	drawBalls(t, e, clock, 1)
+	events = mustDo(t)(e.Claim(7, 1))
+	if len(events) != 2 {
+		t.Fatalf("claim: got %d events, want ClaimAccepted and PhaseChanged", len(events))
+	}
+	accepted, ok := events[0].(ClaimAccepted)
+	if !ok || !accepted.First || accepted.Winner.Player != 7 || accepted.Winner.CardID != 1 {
+		t.Fatalf("claim: got %#v, want the first ClaimAccepted for card 1", events[0])
+	}
+	expectEvents(t, "claim", events[1:], PhaseChanged{From: Drawing, To: Claiming})
+	winner := accepted.Winner
+
+	clock.advance(time.Second)
+	expectEvents(t, "no balls in the claim window", e.Tick())
+	clock.advance(time.Second)
+	expectEvents(t, "claim window closes", e.Tick(),
+		PhaseChanged{From: Claiming, To: Settled}, ClaimsClosed{Winners: []Winner{winner}})
+	_, err = e.Claim(7, 1)
+	expectErr(t, "claim once settled", err, ErrClaimsClosed)
+
+	clock.advance(testConfig.ResultsDelay - time.Millisecond)
+	expectEvents(t, "results still shown", e.Tick())
+	clock.advance(time.Millisecond)
+	expectEvents(t, "round cleared", e.Tick(),
+		RoundEnded{Winners: []Winner{winner}}, PhaseChanged{From: Settled, To: Waiting})
+
+	s := e.Snapshot()
+	if s.Phase != Waiting || len(s.Taken) != 0 || len(s.Drawn) != 0 || len(s.Winners) != 0 || s.Patterns != nil {
+		t.Errorf("after the round: %+v, want an empty waiting round", s)
+	}
+}
+
+func TestCountdownRestartsWithTooFewCards(t *testing.T) {
+	clock := newFakeClock()
+	cfg := testConfig
+	cfg.MinCards = 2
+	e := New(cfg, clock, orderRNG(nil))
+
+	mustDo(t)(e.OpenCountdown(Rules{ClaimWindow: testClaimWindow}))
+	mustDo(t)(e.Select(7, 1, testCard))
+	clock.advance(cfg.Countdown)
+	expectEvents(t, "countdown ends", e.Tick(), PhaseChanged{From: Countdown, To: Waiting})
+	if !e.Taken(1) {
+		t.Error("card was dropped when the countdown restarted")
+	}
+
+	mustDo(t)(e.OpenCountdown(Rules{ClaimWindow: testClaimWindow}))
+	mustDo(t)(e.Select(8, 2, otherCard))
+	clock.advance(cfg.Countdown)
+	expectEvents(t, "countdown ends", e.Tick(),
+		PhaseChanged{From: Countdown, To: Drawing}, RoundStarted{Cards: map[int]uint{1: 7, 2: 8}})
+}
+
+func TestRoundWithoutWinner(t *testing.T) {
+	clock := newFakeClock()
+	cfg := testConfig
+	cfg.Balls = 3
+	e := New(cfg, clock, orderRNG{3, 1, 2})
+
+	mustDo(t)(e.OpenCountdown(Rules{ClaimWindow: testClaimWindow}))
+	mustDo(t)(e.Select(7, 1, testCard))
+	clock.advance(cfg.Countdown)
+	e.Tick()
+	drawBalls(t, e, clock, 3, 1, 2)
+
+	// The last ball gets the claim window instead of another draw interval.
+	clock.advance(cfg.DrawInterval)
+	expectEvents(t, "after the last ball", e.Tick())
+	clock.advance(testClaimWindow - cfg.DrawInterval)
+	expectEvents(t, "all balls drawn", e.Tick(), PhaseChanged{From: Drawing, To: Settled}, ClaimsClosed{})
+	clock.advance(cfg.ResultsDelay)
+	expectEvents(t, "round cleared", e.Tick(), RoundEnded{}, PhaseChanged{From: Settled, To: Waiting})
+}
+
+func TestVoidRound(t *testing.T) {
+	clock := newFakeClock()
+	e := New(testConfig, clock, orderRNG{61, 16, 31, 46, 1})
+
+	mustDo(t)(e.OpenCountdown(Rules{ClaimWindow: testClaimWindow}))
+	_, err := e.Void("too early")
+	expectErr(t, "void in countdown", err, ErrWrongPhase)
+	mustDo(t)(e.Select(7, 1, testCard))
+	clock.advance(testConfig.Countdown)
+	e.Tick()
+	drawBalls(t, e, clock, 61, 16)
+
+	expectEvents(t, "void", mustDo(t)(e.Void("server trouble")),
+		PhaseChanged{From: Drawing, To: Settled}, RoundVoided{Reason: "server trouble"})
+	clock.advance(testConfig.DrawInterval)
+	expectEvents(t, "no balls once voided", e.Tick())
+	clock.advance(testConfig.ResultsDelay)
+	expectEvents(t, "round cleared", e.Tick(), RoundEnded{Voided: true}, PhaseChanged{From: Settled, To: Waiting})
+}
+
+func TestVoidRefusedOnceWon(t *testing.T) {
+	clock := newFakeClock()
+	e := New(testConfig, clock, orderRNG{61, 16, 31, 46, 1})
+
+	mustDo(t)(e.OpenCountdown(Rules{ClaimWindow: testClaimWindow}))
+	mustDo(t)(e.Select(7, 1, testCard))
+	clock.advance(testConfig.Countdown)
+	e.Tick()
+	drawBalls(t, e, clock, 61, 16, 31, 46, 1)
+	mustDo(t)(e.Claim(7, 1))
+
+	_, err := e.Void("too late")
+	expectErr(t, "void with a winner", err, ErrHasWinners)
+}
+
+func TestClaimWindow(t *testing.T) {
+	clock := newFakeClock()
+	e := New(testConfig, clock, orderRNG{61, 16, 31, 46, 1, 6, 21, 36, 51, 66})
+	third := cardFrom(3) // wins with row 3: 6 21 36 51 66
+
+	mustDo(t)(e.OpenCountdown(Rules{ClaimWindow: testClaimWindow}))
+	mustDo(t)(e.Select(7, 1, testCard))
+	mustDo(t)(e.Select(8, 2, testCard))
+	mustDo(t)(e.Select(9, 3, third))
+	clock.advance(testConfig.Countdown)
+	e.Tick()
+	drawBalls(t, e, clock, 61, 16, 31, 46, 1)
+
+	first := mustDo(t)(e.Claim(7, 1))
+	clock.advance(testClaimWindow - time.Millisecond)
+	events := mustDo(t)(e.Claim(8, 2))
+	if len(events) != 1 {
+		t.Fatalf("claim in the window: got %#v, want one ClaimAccepted", events)
+	}
+	if accepted, ok := events[0].(ClaimAccepted); !ok || accepted.First || accepted.Winner.Balls != 5 {
+		t.Fatalf("claim in the window: got %#v, want a ClaimAccepted that isn't first, judged on 5 balls", events[0])
+	}
+	clock.advance(time.Millisecond)
+	_, err := e.Claim(9, 3)
+	expectErr(t, "claim after the window", err, ErrClaimsClosed)
+
+	settled := e.Tick()
+	closed, ok := settled[1].(ClaimsClosed)
+	if !ok || len(closed.Winners) != 2 || !reflect.DeepEqual(closed.Winners[0], first[0].(ClaimAccepted).Winner) {
+		t.Fatalf("settle: got %#v, want both winners", settled)
+	}
+}
+
+func TestSeveralCardsPerPlayer(t *testing.T) {
+	clock := newFakeClock()
+	e := New(testConfig, clock, orderRNG{61, 16, 31, 46, 1})
+
+	mustDo(t)(e.OpenCountdown(Rules{ClaimWindow: testClaimWindow, MaxCards: 2}))
+	mustDo(t)(e.Select(7, 2, otherCard))
+	mustDo(t)(e.Select(7, 1, testCard))
+	_, err := e.Select(7, 3, cardFrom(3))
+	expectErr(t, "third card", err, ErrTooManyCards)
+	expectEvents(t, "swap", mustDo(t)(e.Swap(7, 2, 4, cardFrom(4))), CardSelected{Player: 7, CardID: 4, Previous: 2})
+	expectEvents(t, "deselect", mustDo(t)(e.Deselect(7, 4)), CardReleased{Player: 7, CardID: 4})
+	mustDo(t)(e.Select(7, 2, otherCard))
+
+	clock.advance(testConfig.Countdown)
+	expectEvents(t, "start", e.Tick(),
+		PhaseChanged{From: Countdown, To: Drawing}, RoundStarted{Cards: map[int]uint{1: 7, 2: 7}})
+	drawBalls(t, e, clock, 61, 16, 31, 46, 1)
+
+	// Claiming card 0 checks every card not checked yet, in the order taken.
+	events := mustDo(t)(e.Claim(7, 0))
+	if len(events) != 3 {
+		t.Fatalf("claim all: got %#v, want ClaimAccepted, PhaseChanged and ClaimRejected", events)
+	}
+	if accepted, ok := events[0].(ClaimAccepted); !ok || accepted.Winner.CardID != 1 {
+		t.Errorf("claim all: first event %#v, want ClaimAccepted for card 1", events[0])
+	}
+	expectEvents(t, "claim all", events[1:], PhaseChanged{From: Drawing, To: Claiming}, ClaimRejected{Player: 7, CardID: 2})
+	_, err = e.Claim(7, 0)
+	expectErr(t, "claim all again", err, ErrAlreadyClaimed)
+	_, err = e.Claim(7, 5)
+	expectErr(t, "claim a card the player doesn't have", err, ErrNoCard)
+}
+
+func TestWinnerCellsAndBall(t *testing.T) {
+	all := testCard.Numbers()
+	all = append(all[:12], all[13:]...) // the free centre's 33 is never needed
+
+	tests := []struct {
+		name    string
+		rules   Rules
+		order   []int
+		pattern string
+		cells   []Cell
+		ball    int
+		marked  []string
+		full    bool
+	}{
+		{
+			name:    "row completed by its first column",
+			order:   []int{61, 16, 2, 31, 46, 1},
+			pattern: "row",
+			cells:   []Cell{{0, 0, 1, false}, {0, 1, 16, false}, {0, 2, 31, false}, {0, 3, 46, false}, {0, 4, 61, false}},
+			ball:    1,
+			marked:  []string{"XXXXX", "X....", "..X..", ".....", "....."},
+		},
+		{
+			name:    "balls after the bingo don't complete it",
+			order:   []int{63, 3, 48, 18, 20, 50},
+			pattern: "row",
+			cells:   []Cell{{2, 0, 3, false}, {2, 1, 18, false}, {2, 2, 33, true}, {2, 3, 48, false}, {2, 4, 63, false}},
+			ball:    18,
+			marked:  []string{".....", ".....", "XXXXX", ".....", ".X.X."},
+		},
+		{
+			name:    "round patterns replace the classic ones",
+			rules:   Rules{Patterns: []Pattern{{Name: "centre_x", Masks: []Mask{bit(1, 1) | bit(1, 3) | bit(2, 2) | bit(3, 1) | bit(3, 3)}}}},
+			order:   []int{1, 16, 31, 46, 61, 17, 49, 19, 47},
+			pattern: "centre_x",
+			cells:   []Cell{{1, 1, 17, false}, {1, 3, 47, false}, {2, 2, 33, true}, {3, 1, 19, false}, {3, 3, 49, false}},
+			ball:    47,
+			marked:  []string{"XXXXX", ".X.X.", "..X..", ".X.X.", "....."},
+		},
+		{
+			name:    "full card within the jackpot balls",
+			rules:   Rules{JackpotMaxBalls: 24, Patterns: []Pattern{Classic[len(Classic)-1]}},
+			order:   all,
+			pattern: "full_card",
+			cells:   testCard.Cells(FullMask),
+			ball:    65,
+			marked:  FullMask.Rows(),
+			full:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			e := New(testConfig, clock, orderRNG(tt.order))
+			tt.rules.ClaimWindow = testClaimWindow
+			mustDo(t)(e.OpenCountdown(tt.rules))
+			mustDo(t)(e.Select(7, 1, testCard))
+			clock.advance(testConfig.Countdown)
+			e.Tick()
+			drawBalls(t, e, clock, tt.order...)
+
+			events := mustDo(t)(e.Claim(7, 1))
+			w := events[0].(ClaimAccepted).Winner
+			if w.Pattern.Name != tt.pattern {
+				t.Errorf("pattern = %s, want %s", w.Pattern.Name, tt.pattern)
+			}
+			if !reflect.DeepEqual(w.Cells, tt.cells) {
+				t.Errorf("cells = %v, want %v", w.Cells, tt.cells)
+			}
+			if w.Ball != tt.ball {
+				t.Errorf("completing ball = %d, want %d", w.Ball, tt.ball)
+			}
+			if w.Balls != len(tt.order) {
+				t.Errorf("balls = %d, want %d", w.Balls, len(tt.order))
+			}
+			if got := w.Marked.Rows(); !reflect.DeepEqual(got, tt.marked) {
+				t.Errorf("marked = %v, want %v", got, tt.marked)
+			}
+			if w.FullCard != tt.full {
+				t.Errorf("full card = %v, want %v", w.FullCard, tt.full)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package game
+
+// Event is something that happened in a round. The engine returns events
+// from every command and tick; the caller decides what they mean for
+// money, storage and players.
+type Event interface {
+	isEvent()
+}
+
+// PhaseChanged is emitted on every state transition.
+type PhaseChanged struct {
+	From, To Phase
+}
+
+// CountdownTick is emitted when the whole seconds left in the countdown
+// change.
+type CountdownTick struct {
+	Remaining int
+}
+
+// CardSelected is emitted when a player takes a card. Previous is the card
+// they swapped for it, or 0.
+type CardSelected struct {
+	Player   uint
+	CardID   int
+	Previous int
+}
+
+// CardReleased is emitted when a player's card goes back to the pool.
+type CardReleased struct {
+	Player uint
+	CardID int
+}
+
+// RoundStarted is emitted when the countdown ends with enough cards.
+// Cards maps every card in the round to its player.
+type RoundStarted struct {
+	Cards map[int]uint
+}
+
+// BallDrawn is emitted for every ball; Count is how many have been drawn.
+type BallDrawn struct {
+	Ball  int
+	Count int
+}
+
+// ClaimAccepted is emitted for a valid bingo. First is set for the claim
+// that opened the claim window.
+type ClaimAccepted struct {
+	Winner Winner
+	First  bool
+}
+
+// ClaimRejected is emitted when a player claims without a bingo.
+type ClaimRejected struct {
+	Player uint
+	CardID int
+}
+
+// ClaimsClosed is emitted when the round is decided: the claim window has
+// closed, or every ball was drawn without a claim and Winners is empty.
+type ClaimsClosed struct {
+	Winners []Winner
+}
+
+// RoundVoided is emitted when a running round is called off.
+type RoundVoided struct {
+	Reason string
+}
+
+// RoundEnded is emitted when a settled round is cleared for the next one.
+type RoundEnded struct {
+	Voided  bool
+	Winners []Winner
+}
+
+func (PhaseChanged) isEvent()  {}
+func (CountdownTick) isEvent() {}
+func (CardSelected) isEvent()  {}
+func (CardReleased) isEvent()  {}
+func (RoundStarted) isEvent()  {}
+func (BallDrawn) isEvent()     {}
+func (ClaimAccepted) isEvent() {}
+func (ClaimRejected) isEvent() {}
+func (ClaimsClosed) isEvent()  {}
+func (RoundVoided) isEvent()   {}
+func (RoundEnded) isEvent()    {}
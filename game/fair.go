@@ -0,0 +1,145 @@
+package game
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Provably fair draws use a commit–reveal scheme.
+//
+// When a round's countdown opens the server picks a random server seed and
+// publishes its commitment, the hex SHA-256 of the seed's text. Players may
+// send client seeds until the countdown ends; the round's client seed is
+// every contribution as "player:seed", sorted by player and joined with ",",
+// or "" when nobody sent one. The draw order is then fixed:
+//
+//  1. Start from the balls 1..n in order.
+//  2. Shuffle them with Fisher–Yates from the last position down: for
+//     i = n-1 down to 1, pick j uniformly in [0, i] and swap positions i
+//     and j.
+//  3. The random numbers come from HMAC-SHA256 keyed with the server seed's
+//     text, over the message clientSeed + ":" + counter for counter 0, 1,
+//     2, ... Each 32-byte block is read as eight big-endian uint32 values
+//     in order. A value v gives j = v mod (i+1), unless v falls in the last
+//     2^32 mod (i+1) values, in which case it is skipped so every j is
+//     equally likely.
+//
+// Once the round is settled the server seed is revealed. Anyone can check it
+// against the commitment and recompute the order with DrawOrder.
+const FairAlgorithm = "hmac-sha256-fisher-yates-v1"
+
+// MaxClientSeed is the longest client seed a player can contribute.
+const MaxClientSeed = 64
+
+// FairDraw holds the seeds of one round. It is an RNG, so a round drawn
+// with it follows FairAlgorithm.
+type FairDraw struct {
+	ServerSeed  string
+	clientSeeds map[uint]string
+}
+
+// NewFairDraw picks a fresh server seed.
+func NewFairDraw() (*FairDraw, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return &FairDraw{ServerSeed: hex.EncodeToString(b), clientSeeds: make(map[uint]string)}, nil
+}
+
+// Commit returns the published commitment to a server seed.
+func Commit(serverSeed string) string {
+	sum := sha256.Sum256([]byte(serverSeed))
+	return hex.EncodeToString(sum[:])
+}
+
+// Commitment is the hash published before the round starts.
+func (f *FairDraw) Commitment() string { return Commit(f.ServerSeed) }
+
+// AddClientSeed records the player's contribution, replacing any earlier
+// one. Seeds must be 1 to MaxClientSeed printable characters.
+func (f *FairDraw) AddClientSeed(player uint, seed string) error {
+	if seed == "" || len(seed) > MaxClientSeed {
+		return fmt.Errorf("client seed must be 1 to %d characters", MaxClientSeed)
+	}
+	for _, r := range seed {
+		if r < 0x21 || r > 0x7e {
+			return fmt.Errorf("client seed may only use printable ASCII without spaces")
+		}
+	}
+	f.clientSeeds[player] = seed
+	return nil
+}
+
+// ClientSeed is the round's combined client seed.
+func (f *FairDraw) ClientSeed() string {
+	players := make([]uint, 0, len(f.clientSeeds))
+	for p := range f.clientSeeds {
+		players = append(players, p)
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i] < players[j] })
+	parts := make([]string, len(players))
+	for i, p := range players {
+		parts[i] = strconv.FormatUint(uint64(p), 10) + ":" + f.clientSeeds[p]
+	}
+	return strings.Join(parts, ",")
+}
+
+// Shuffle shuffles n items the FairAlgorithm way.
+func (f *FairDraw) Shuffle(n int, swap func(i, j int)) {
+	shuffle(f.ServerSeed, f.ClientSeed(), n, swap)
+}
+
+// DrawOrder recomputes the full draw order of n balls from a round's seeds.
+func DrawOrder(serverSeed, clientSeed string, n int) []int {
+	balls := make([]int, n)
+	for i := range balls {
+		balls[i] = i + 1
+	}
+	shuffle(serverSeed, clientSeed, n, func(i, j int) { balls[i], balls[j] = balls[j], balls[i] })
+	return balls
+}
+
+func shuffle(serverSeed, clientSeed string, n int, swap func(i, j int)) {
+	s := &seedStream{key: []byte(serverSeed), msg: clientSeed}
+	for i := n - 1; i > 0; i-- {
+		swap(i, s.below(uint32(i+1)))
+	}
+}
+
+// seedStream reads uint32 values from the HMAC blocks of FairAlgorithm.
+type seedStream struct {
+	key     []byte
+	msg     string
+	counter int
+	block   []byte
+}
+
+func (s *seedStream) next() uint32 {
+	if len(s.block) == 0 {
+		mac := hmac.New(sha256.New, s.key)
+		mac.Write([]byte(s.msg + ":" + strconv.Itoa(s.counter)))
+		s.block = mac.Sum(nil)
+		s.counter++
+	}
+	v := binary.BigEndian.Uint32(s.block)
+	s.block = s.block[4:]
+	return v
+}
+
+// below returns a uniform value in [0, n).
+func (s *seedStream) below(n uint32) int {
+	limit := ^uint32(0) - (^uint32(0)%n+1)%n // largest v with an unbiased v mod n
+	for {
+		if v := s.next(); v <= limit {
+			return int(v % n)
+		}
+	}
+}
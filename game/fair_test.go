@@ -0,0 +1,86 @@
+package game
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Known answers for FairAlgorithm, computed with an independent
+// implementation of the steps documented in fair.go. Changing any of them
+// changes the draw of every round already played.
+var drawOrderVectors = []struct {
+	serverSeed, clientSeed string
+	balls                  int
+	want                   []int
+}{
+	{"abc", "1:x", 10, []int{5, 9, 4, 2, 7, 6, 8, 3, 1, 10}},
+	{"abc", "", 10, []int{7, 8, 4, 10, 6, 5, 2, 1, 3, 9}},
+	{
+		"5f0c2a7e9d3b41c6a8e2f1d07b9c3e54a6d8f20e1c4b7a9d3e5f60718293a4b5", "7:lucky,12:seven", 75,
+		[]int{
+			53, 58, 13, 10, 40, 34, 68, 11, 1, 38, 27, 61, 18, 31, 69, 17, 21, 29, 25, 39, 37, 19, 2, 66, 23,
+			52, 45, 73, 4, 62, 30, 55, 8, 16, 71, 9, 24, 6, 22, 51, 64, 59, 3, 14, 48, 72, 28, 57, 20, 26,
+			32, 7, 5, 65, 63, 35, 70, 47, 46, 56, 12, 43, 33, 54, 44, 60, 36, 50, 42, 74, 67, 49, 15, 75, 41,
+		},
+	},
+}
+
+func TestDrawOrderKnownAnswers(t *testing.T) {
+	for _, v := range drawOrderVectors {
+		if got := DrawOrder(v.serverSeed, v.clientSeed, v.balls); !reflect.DeepEqual(got, v.want) {
+			t.Errorf("DrawOrder(%q, %q, %d) = %v, want %v", v.serverSeed, v.clientSeed, v.balls, got, v.want)
+		}
+	}
+}
+
+func TestCommit(t *testing.T) {
+	// SHA-256 of "abc", the FIPS 180-2 test vector.
+	const want = "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if got := Commit("abc"); got != want {
+		t.Errorf("Commit(abc) = %s, want %s", got, want)
+	}
+}
+
+func TestFairDrawClientSeed(t *testing.T) {
+	f := &FairDraw{ServerSeed: "abc", clientSeeds: make(map[uint]string)}
+	if got := f.ClientSeed(); got != "" {
+		t.Errorf("ClientSeed with no seeds = %q, want empty", got)
+	}
+	for _, s := range []struct {
+		player uint
+		seed   string
+	}{{12, "first"}, {7, "lucky"}, {12, "seven"}} {
+		if err := f.AddClientSeed(s.player, s.seed); err != nil {
+			t.Fatalf("AddClientSeed(%d, %q): %v", s.player, s.seed, err)
+		}
+	}
+	if got, want := f.ClientSeed(), "7:lucky,12:seven"; got != want {
+		t.Errorf("ClientSeed = %q, want %q", got, want)
+	}
+
+	for _, bad := range []string{"", "has space", "tab\there", strings.Repeat("a", MaxClientSeed+1)} {
+		if err := f.AddClientSeed(1, bad); err == nil {
+			t.Errorf("AddClientSeed(%q) accepted", bad)
+		}
+	}
+}
+
+func TestEngineDrawsFairOrder(t *testing.T) {
+	v := drawOrderVectors[2]
+	f := &FairDraw{ServerSeed: v.serverSeed, clientSeeds: map[uint]string{7: "lucky", 12: "seven"}}
+
+	clock := newFakeClock()
+	e := New(testConfig, clock, orderRNG(nil))
+	mustDo(t)(e.OpenCountdown(Rules{ClaimWindow: testClaimWindow, Draw: f}))
+	mustDo(t)(e.Select(7, 1, testCard))
+	clock.advance(testConfig.Countdown)
+	e.Tick()
+	for range v.want {
+		clock.advance(testConfig.DrawInterval)
+		e.Tick()
+	}
+	if got := e.Snapshot().Drawn; !reflect.DeepEqual(got, v.want) {
+		t.Errorf("engine drew %v, want %v", got, v.want)
+	}
+}
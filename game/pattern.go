@@ -0,0 +1,155 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Mask is a set of squares on a card, one bit per square, bit row*Size+col.
+type Mask uint32
+
+// FullMask covers the whole card.
+const FullMask Mask = 1<<(Size*Size) - 1
+
+func bit(row, col int) Mask { return 1 << (row*Size + col) }
+
+// ParseMask reads a mask drawn as Size rows of Size characters, where X
+// marks a square that must be covered and . one that doesn't matter.
+func ParseMask(rows []string) (Mask, error) {
+	if len(rows) != Size {
+		return 0, fmt.Errorf("mask needs %d rows, got %d", Size, len(rows))
+	}
+	var m Mask
+	for row, line := range rows {
+		if len(line) != Size {
+			return 0, fmt.Errorf("mask row %d needs %d squares, got %q", row+1, Size, line)
+		}
+		for col, ch := range line {
+			switch ch {
+			case 'X', 'x':
+				m |= bit(row, col)
+			case '.':
+			default:
+				return 0, fmt.Errorf("mask row %d: %q is not X or .", row+1, ch)
+			}
+		}
+	}
+	if m == 0 {
+		return 0, errors.New("mask covers no squares")
+	}
+	return m, nil
+}
+
+// Rows draws the mask the way ParseMask reads it.
+func (m Mask) Rows() []string {
+	rows := make([]string, Size)
+	for row := range rows {
+		var b strings.Builder
+		for col := 0; col < Size; col++ {
+			if m&bit(row, col) != 0 {
+				b.WriteByte('X')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		rows[row] = b.String()
+	}
+	return rows
+}
+
+// Pattern is a named way to win. A card wins with it when every square of
+// any one of its masks is covered, e.g. "row" has a mask for each row.
+type Pattern struct {
+	Name  string
+	Label string
+	Masks []Mask
+}
+
+// patternJSON is how a pattern is written in a library file and sent to
+// players: masks are drawn as rows.
+type patternJSON struct {
+	Name  string     `json:"name"`
+	Label string     `json:"label"`
+	Masks [][]string `json:"masks"`
+}
+
+func (p Pattern) MarshalJSON() ([]byte, error) {
+	out := patternJSON{Name: p.Name, Label: p.Label, Masks: make([][]string, len(p.Masks))}
+	for i, m := range p.Masks {
+		out.Masks[i] = m.Rows()
+	}
+	return json.Marshal(out)
+}
+
+func (p *Pattern) UnmarshalJSON(data []byte) error {
+	var in patternJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if in.Name == "" {
+		return errors.New("pattern has no name")
+	}
+	if len(in.Masks) == 0 {
+		return fmt.Errorf("pattern %s has no masks", in.Name)
+	}
+	p.Name, p.Label, p.Masks = in.Name, in.Label, make([]Mask, len(in.Masks))
+	if p.Label == "" {
+		p.Label = in.Name
+	}
+	for i, rows := range in.Masks {
+		m, err := ParseMask(rows)
+		if err != nil {
+			return fmt.Errorf("pattern %s: %w", in.Name, err)
+		}
+		p.Masks[i] = m
+	}
+	return nil
+}
+
+// ParsePatterns reads a pattern library: a JSON list of patterns with
+// unique names.
+func ParsePatterns(data []byte) ([]Pattern, error) {
+	var patterns []Pattern
+	if err := json.Unmarshal(data, &patterns); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(patterns))
+	for _, p := range patterns {
+		if seen[p.Name] {
+			return nil, fmt.Errorf("pattern %s is defined twice", p.Name)
+		}
+		seen[p.Name] = true
+	}
+	return patterns, nil
+}
+
+// Classic are the patterns used when a round names none: the four corners,
+// any row, column or diagonal, the centre cross and the full card.
+var Classic = classicPatterns()
+
+func classicPatterns() []Pattern {
+	corners := bit(0, 0) | bit(0, Size-1) | bit(Size-1, 0) | bit(Size-1, Size-1)
+	var rows, cols []Mask
+	var cross, diag, anti Mask
+	for i := 0; i < Size; i++ {
+		var row, col Mask
+		for j := 0; j < Size; j++ {
+			row |= bit(i, j)
+			col |= bit(j, i)
+		}
+		rows, cols = append(rows, row), append(cols, col)
+		cross |= bit(Size/2, i) | bit(i, Size/2)
+		diag |= bit(i, i)
+		anti |= bit(i, Size-1-i)
+	}
+	return []Pattern{
+		{Name: "four_corners", Label: "Four corners", Masks: []Mask{corners}},
+		{Name: "row", Label: "Any row", Masks: rows},
+		{Name: "column", Label: "Any column", Masks: cols},
+		{Name: "cross", Label: "Centre cross", Masks: []Mask{cross}},
+		{Name: "diagonal", Label: "Any diagonal", Masks: []Mask{diag, anti}},
+		{Name: "full_card", Label: "Full card", Masks: []Mask{FullMask}},
+	}
+}
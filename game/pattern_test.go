@@ -0,0 +1,222 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// marks draws the numbers of testCard at the given squares.
+func marks(squares ...[2]int) map[int]bool {
+	drawn := make(map[int]bool, len(squares))
+	for _, sq := range squares {
+		drawn[testCard[sq[0]][sq[1]]] = true
+	}
+	return drawn
+}
+
+func TestClassicPatterns(t *testing.T) {
+	tests := []struct {
+		name  string
+		drawn map[int]bool
+		want  string // pattern name, or "" for no bingo
+		mask  []string
+	}{
+		{
+			name:  "top row",
+			drawn: marks([2]int{0, 0}, [2]int{0, 1}, [2]int{0, 2}, [2]int{0, 3}, [2]int{0, 4}),
+			want:  "row",
+			mask:  []string{"XXXXX", ".....", ".....", ".....", "....."},
+		},
+		{
+			name:  "bottom row",
+			drawn: marks([2]int{4, 0}, [2]int{4, 1}, [2]int{4, 2}, [2]int{4, 3}, [2]int{4, 4}),
+			want:  "row",
+			mask:  []string{".....", ".....", ".....", ".....", "XXXXX"},
+		},
+		{
+			name:  "middle row through the free centre",
+			drawn: marks([2]int{2, 0}, [2]int{2, 1}, [2]int{2, 3}, [2]int{2, 4}),
+			want:  "row",
+			mask:  []string{".....", ".....", "XXXXX", ".....", "....."},
+		},
+		{
+			name:  "first column",
+			drawn: marks([2]int{0, 0}, [2]int{1, 0}, [2]int{2, 0}, [2]int{3, 0}, [2]int{4, 0}),
+			want:  "column",
+			mask:  []string{"X....", "X....", "X....", "X....", "X...."},
+		},
+		{
+			name:  "N column through the free centre",
+			drawn: marks([2]int{0, 2}, [2]int{1, 2}, [2]int{3, 2}, [2]int{4, 2}),
+			want:  "column",
+			mask:  []string{"..X..", "..X..", "..X..", "..X..", "..X.."},
+		},
+		{
+			name:  "diagonal",
+			drawn: marks([2]int{0, 0}, [2]int{1, 1}, [2]int{3, 3}, [2]int{4, 4}),
+			want:  "diagonal",
+			mask:  []string{"X....", ".X...", "..X..", "...X.", "....X"},
+		},
+		{
+			name:  "anti-diagonal",
+			drawn: marks([2]int{0, 4}, [2]int{1, 3}, [2]int{3, 1}, [2]int{4, 0}),
+			want:  "diagonal",
+			mask:  []string{"....X", "...X.", "..X..", ".X...", "X...."},
+		},
+		{
+			name:  "four corners",
+			drawn: marks([2]int{0, 0}, [2]int{0, 4}, [2]int{4, 0}, [2]int{4, 4}),
+			want:  "four_corners",
+			mask:  []string{"X...X", ".....", ".....", ".....", "X...X"},
+		},
+		{
+			name:  "patterns are tried in order",
+			drawn: marks([2]int{0, 0}, [2]int{0, 1}, [2]int{0, 2}, [2]int{0, 3}, [2]int{0, 4}, [2]int{4, 0}, [2]int{4, 4}),
+			want:  "four_corners",
+			mask:  []string{"X...X", ".....", ".....", ".....", "X...X"},
+		},
+		{name: "nothing drawn, only the free centre", drawn: map[int]bool{}},
+		{name: "row one short", drawn: marks([2]int{0, 0}, [2]int{0, 1}, [2]int{0, 2}, [2]int{0, 3})},
+		{name: "middle row one short", drawn: marks([2]int{2, 0}, [2]int{2, 1}, [2]int{2, 3})},
+		{name: "three corners", drawn: marks([2]int{0, 0}, [2]int{0, 4}, [2]int{4, 0})},
+		{name: "diagonal one short", drawn: marks([2]int{0, 0}, [2]int{1, 1}, [2]int{3, 3})},
+		{name: "numbers not on the card", drawn: map[int]bool{6: true, 21: true, 36: true, 51: true, 66: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, m, ok := testCard.Match(tt.drawn, Classic)
+			if ok != (tt.want != "") {
+				t.Fatalf("Match = %s, %v; want bingo %v", p.Name, ok, tt.want != "")
+			}
+			if !ok {
+				return
+			}
+			if p.Name != tt.want {
+				t.Errorf("pattern = %s, want %s", p.Name, tt.want)
+			}
+			if got := m.Rows(); !reflect.DeepEqual(got, tt.mask) {
+				t.Errorf("mask = %v, want %v", got, tt.mask)
+			}
+			if testCard.HasBingo(tt.drawn) != ok {
+				t.Errorf("HasBingo disagrees with Match")
+			}
+		})
+	}
+}
+
+func TestFullCard(t *testing.T) {
+	all := make(map[int]bool)
+	for _, n := range testCard.Numbers() {
+		all[n] = true
+	}
+	if !testCard.FullCard(all) {
+		t.Error("every number drawn is not a full card")
+	}
+	delete(all, testCard[2][2])
+	if !testCard.FullCard(all) {
+		t.Error("the free centre's number should not be needed")
+	}
+	delete(all, testCard[4][4])
+	if testCard.FullCard(all) {
+		t.Error("a card with a square missing is a full card")
+	}
+}
+
+func TestParseMask(t *testing.T) {
+	rows := []string{"X...x", ".....", "..X..", ".....", "X...X"}
+	m, err := ParseMask(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := bit(0, 0) | bit(0, 4) | bit(2, 2) | bit(4, 0) | bit(4, 4)
+	if m != want {
+		t.Errorf("ParseMask = %b, want %b", m, want)
+	}
+	if got := m.Rows(); !reflect.DeepEqual(got, []string{"X...X", ".....", "..X..", ".....", "X...X"}) {
+		t.Errorf("Rows = %v", got)
+	}
+
+	for name, bad := range map[string][]string{
+		"too few rows":   {"XXXXX", "XXXXX", "XXXXX", "XXXXX"},
+		"short row":      {"XXXX", ".....", ".....", ".....", "....."},
+		"unknown mark":   {"XXXXO", ".....", ".....", ".....", "....."},
+		"covers nothing": {".....", ".....", ".....", ".....", "....."},
+	} {
+		if _, err := ParseMask(bad); err == nil {
+			t.Errorf("%s: ParseMask(%v) accepted", name, bad)
+		}
+	}
+}
+
+func TestParsePatterns(t *testing.T) {
+	data, err := json.Marshal(Classic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParsePatterns(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, Classic) {
+		t.Errorf("Classic did not survive a JSON round trip:\n got %v\nwant %v", got, Classic)
+	}
+
+	unlabelled, err := ParsePatterns([]byte(`[{"name":"centre","masks":[[".....",".....","..X..",".....","....."]]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unlabelled[0].Label != "centre" {
+		t.Errorf("label = %q, want the name", unlabelled[0].Label)
+	}
+
+	for name, bad := range map[string]string{
+		"duplicate name": `[{"name":"a","masks":[["X....",".....",".....",".....","....."]]},` +
+			`{"name":"a","masks":[[".....",".....",".....",".....","....X"]]}]`,
+		"no name":  `[{"masks":[["X....",".....",".....",".....","....."]]}]`,
+		"no masks": `[{"name":"a","masks":[]}]`,
+		"bad mask": `[{"name":"a","masks":[["X"]]}]`,
+		"not JSON": `rows`,
+	} {
+		if _, err := ParsePatterns([]byte(bad)); err == nil {
+			t.Errorf("%s: ParsePatterns accepted %s", name, bad)
+		}
+	}
+}
+
+// The shipped library must parse and agree with Classic wherever it reuses
+// a classic name.
+func TestPatternLibraryFile(t *testing.T) {
+	data, err := os.ReadFile("../patterns.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	library, err := ParsePatterns(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]Pattern, len(library))
+	for _, p := range library {
+		byName[p.Name] = p
+	}
+	for _, c := range Classic {
+		p, ok := byName[c.Name]
+		if !ok {
+			t.Errorf("library has no %s pattern", c.Name)
+			continue
+		}
+		if !reflect.DeepEqual(p.Masks, c.Masks) {
+			t.Errorf("library %s masks = %v, want %v", c.Name, masksRows(p.Masks), masksRows(c.Masks))
+		}
+	}
+}
+
+func masksRows(masks []Mask) string {
+	out := make([]string, len(masks))
+	for i, m := range masks {
+		out[i] = strings.Join(m.Rows(), "/")
+	}
+	return strings.Join(out, " ")
+}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"log"
-	"net/http"
 	"os"
 	"time"
 
 	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/controllers"
+	"github.com/bellapacxx/bingo-backend/payments"
+	"github.com/bellapacxx/bingo-backend/reconcile"
 	"github.com/bellapacxx/bingo-backend/routes"
 	"github.com/bellapacxx/bingo-backend/services"
 
@@ -39,7 +41,7 @@ func setupRouter() *gin.Engine {
 			"http://localhost:3000",
 			"https://bot-frontend-urwm.vercel.app","https://bot-frontend-8lzr.vercel.app"}, // your frontend origin
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "Idempotency-Key", "X-Admin-Token", "X-Admin-User"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
@@ -48,9 +50,7 @@ func setupRouter() *gin.Engine {
 	routes.SetupRoutes(r)
 
 	// Health check endpoint
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok", "timestamp": time.Now()})
-	})
+	r.GET("/health", controllers.Health)
 
 	// WebSocket lobby endpoint
 	r.GET("/ws/:stake", services.HandleWebSocket)
@@ -66,9 +66,18 @@ func main() {
 	// Connect to database
 	config.SetupDatabase()
 
+	// Register deposit verification providers
+	payments.SetupVerifiers()
+
 	// Initialize in-memory lobby service
 	services.InitLobbyService()
 
+	// Check player balances against the ledger every night
+	reconcile.StartBalanceJob(config.DB)
+
+	// Forfeit bonus money whose promotion has run out
+	services.StartBonusExpiryJob()
+
 	// Setup Gin router
 	router := setupRouter()
 
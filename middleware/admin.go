@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	AdminTokenHeader = "X-Admin-Token"
+	AdminUserHeader  = "X-Admin-User" // optional, recorded on reviewed items
+)
+
+// AdminAuth guards operator endpoints with the shared ADMIN_TOKEN secret.
+// Admin routes stay closed when ADMIN_TOKEN is not set.
+func AdminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := os.Getenv("ADMIN_TOKEN")
+		given := c.GetHeader(AdminTokenHeader)
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(given)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "admin token required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// AdminUser returns who is acting on an admin endpoint, for audit fields.
+func AdminUser(c *gin.Context) string {
+	if u := c.GetHeader(AdminUserHeader); u != "" {
+		return u
+	}
+	return "admin"
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	IdempotencyHeader = "Idempotency-Key"
+	ReplayedHeader    = "Idempotent-Replayed"
+)
+
+// Idempotent requires an Idempotency-Key header and answers a retried
+// request with the response to the first one instead of running it again.
+// The key is claimed in a short transaction of its own and the response is
+// stored under it once the handler is done, so no connection or row lock is
+// held while the handler calls out to a payment provider; handlers open
+// their own transactions. A repeat with the same key and body gets the saved
+// response back, a repeat while the first request is still running gets
+// 409, and so does a repeat with a different body.
+func Idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(IdempotencyHeader))
+		if key == "" || len(key) > 255 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key header is required"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+
+		// A claimed key without a status code is a request still running.
+		record := models.IdempotencyKey{
+			Scope:       c.Request.Method + " " + c.FullPath(),
+			Key:         key,
+			RequestHash: hex.EncodeToString(sum[:]),
+		}
+		res := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if res.Error != nil {
+			log.Printf("[ERROR] Failed to store idempotency key: %v", res.Error)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if res.RowsAffected == 0 {
+			replay(c, record)
+			return
+		}
+
+		w := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = w
+		defer func() {
+			if r := recover(); r != nil {
+				release(record)
+				panic(r)
+			}
+		}()
+
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		// Server errors are not remembered so the client can retry them.
+		if w.status >= http.StatusInternalServerError {
+			release(record)
+			w.flush()
+			return
+		}
+
+		// The handler has committed by now, so its response goes out even if
+		// it can't be stored; the key then stays claimed and repeats get 409
+		// rather than running the request twice.
+		if err := config.DB.Model(&record).Updates(map[string]any{
+			"status_code":   w.status,
+			"response_body": w.buf.Bytes(),
+		}).Error; err != nil {
+			log.Printf("[ERROR] Failed to store response to idempotent request %s: %v", key, err)
+		}
+		w.flush()
+	}
+}
+
+// release gives up a claimed key so the request can be retried with it.
+func release(record models.IdempotencyKey) {
+	if err := config.DB.Delete(&record).Error; err != nil {
+		log.Printf("[ERROR] Failed to release idempotency key %s: %v", record.Key, err)
+	}
+}
+
+func replay(c *gin.Context, record models.IdempotencyKey) {
+	var existing models.IdempotencyKey
+	if err := config.DB.Where("scope = ? AND key = ?", record.Scope, record.Key).First(&existing).Error; err != nil {
+		log.Printf("[ERROR] Failed to load idempotency key %s: %v", record.Key, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if existing.RequestHash != record.RequestHash {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Idempotency-Key was already used with a different request"})
+		return
+	}
+	if existing.StatusCode == 0 {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is still being processed"})
+		return
+	}
+
+	c.Header(ReplayedHeader, "true")
+	c.Data(existing.StatusCode, "application/json; charset=utf-8", existing.ResponseBody)
+	c.Abort()
+}
+
+// bufferedWriter holds the handler's response until it has been stored, so
+// a repeat can't be answered differently from the first request.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	buf    bytes.Buffer
+	status int
+	wrote  bool
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+	w.wrote = true
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	w.wrote = true
+	return w.buf.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.wrote = true
+	return w.buf.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int   { return w.status }
+func (w *bufferedWriter) Size() int     { return w.buf.Len() }
+func (w *bufferedWriter) Written() bool { return w.wrote }
+
+func (w *bufferedWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	if _, err := w.ResponseWriter.Write(w.buf.Bytes()); err != nil {
+		log.Printf("[ERROR] Failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// BalanceReport is the stored result of recomputing every player's balance
+// from the ledger and comparing it with users.balance.
+type BalanceReport struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	Users      int            `json:"users"`                         // players checked
+	Drifted    int            `json:"drifted"`                       // balance differs from the ledger
+	Unbooked   int            `json:"unbooked"`                      // balance but no ledger wallet yet
+	TotalDrift Money          `gorm:"type:bigint" json:"totalDrift"` // sum of absolute differences
+	Entries    datatypes.JSON `json:"entries,omitempty"`
+	CreatedBy  string         `json:"createdBy"` // "job" or "cli"
+	StartedAt  time.Time      `json:"startedAt"`
+	CreatedAt  time.Time      `json:"createdAt"`
+}
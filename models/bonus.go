@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// WinningsRule says where winnings paid on bonus-funded stakes go.
+type WinningsRule string
+
+const (
+	WinningsToBonus WinningsRule = "bonus" // stay bonus money until wagering is met
+	WinningsToCash  WinningsRule = "cash"  // withdrawable straight away
+)
+
+// BonusPromotion is a template for granting bonus money.
+type BonusPromotion struct {
+	ID                 uint         `gorm:"primaryKey" json:"id"`
+	Code               string       `gorm:"uniqueIndex;size:64;not null" json:"code"`
+	Name               string       `json:"name"`
+	WageringMultiplier int          `gorm:"not null" json:"wageringMultiplier"` // stake the bonus this many times
+	ValidDays          int          `gorm:"not null" json:"validDays"`
+	WinningsTo         WinningsRule `gorm:"size:16;not null;default:bonus" json:"winningsTo"`
+	Active             bool         `gorm:"not null" json:"active"`
+	CreatedAt          time.Time    `json:"createdAt"`
+	UpdatedAt          time.Time    `json:"updatedAt"`
+}
+
+type BonusStatus string
+
+const (
+	BonusActive    BonusStatus = "active"    // wagering still to do
+	BonusConverted BonusStatus = "converted" // wagering met, bonus moved to cash
+	BonusExpired   BonusStatus = "expired"   // ran out of time, bonus forfeited
+)
+
+// BonusGrant is bonus money given to one player under a promotion. Stakes
+// count towards the oldest active grant first.
+type BonusGrant struct {
+	ID               uint         `gorm:"primaryKey" json:"id"`
+	UserID           uint         `gorm:"index;not null" json:"userId"`
+	PromotionID      uint         `gorm:"index;not null" json:"promotionId"`
+	Amount           Money        `gorm:"type:bigint;not null" json:"amount"`
+	WageringRequired Money        `gorm:"type:bigint;not null" json:"wageringRequired"`
+	Wagered          Money        `gorm:"type:bigint;not null;default:0" json:"wagered"`
+	WinningsTo       WinningsRule `gorm:"size:16;not null" json:"winningsTo"`
+	Status           BonusStatus  `gorm:"index;not null" json:"status"`
+	ExpiresAt        time.Time    `gorm:"index" json:"expiresAt"`
+	GrantedBy        string       `json:"grantedBy,omitempty"`
+	ResolvedAt       *time.Time   `json:"resolvedAt,omitempty"`
+	CreatedAt        time.Time    `json:"createdAt"`
+	UpdatedAt        time.Time    `json:"updatedAt"`
+}
@@ -6,12 +6,30 @@ import (
 	"gorm.io/gorm"
 )
 
+type DepositStatus string
+
+const (
+	DepositCredited DepositStatus = "credited" // verified and added to the wallet
+	DepositDisputed DepositStatus = "disputed" // provider disagrees with the player, held for review
+)
+
+// VoucherProvider is the provider of deposits paid with a scratch voucher.
+// No money arrives with them, so they appear on no statement.
+const VoucherProvider = "voucher"
+
 type Deposit struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	UserID    uint           `gorm:"not null" json:"userId"`
-	Amount    float64        `gorm:"not null" json:"amount"`
-	Reference string         `gorm:"uniqueIndex;not null" json:"reference"`
-	CreatedAt time.Time      `json:"createdAt"`
-	UpdatedAt time.Time      `json:"updatedAt"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	UserID         uint           `gorm:"not null" json:"userId"`
+	Amount         Money          `gorm:"type:bigint;not null" json:"amount"`                   // amount confirmed by the provider
+	ExpectedAmount Money          `gorm:"type:bigint;not null;default:0" json:"expectedAmount"` // amount the player claimed
+	Currency       string         `gorm:"size:3;not null;default:ETB" json:"currency"`
+	Reference      string         `gorm:"uniqueIndex;not null" json:"reference"`
+	Provider       string         `gorm:"index" json:"provider"`
+	Payer          string         `json:"payer"`
+	PaidAt         *time.Time     `json:"paidAt,omitempty"`
+	Status         DepositStatus  `gorm:"index;not null;default:credited" json:"status"`
+	DisputeReason  string         `json:"disputeReason,omitempty"` // why a disputed deposit is held
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
 }
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+type DepositIntentStatus string
+
+const (
+	IntentPending DepositIntentStatus = "pending" // waiting for the player's payment
+	IntentMatched DepositIntentStatus = "matched" // paid and credited
+	IntentExpired DepositIntentStatus = "expired"
+)
+
+// DepositIntent is a player's announcement that they are about to pay a
+// given amount. Forwarded SMS receipts are matched against open intents.
+type DepositIntent struct {
+	ID        uint                `gorm:"primaryKey" json:"id"`
+	UserID    uint                `gorm:"index;not null" json:"userId"`
+	Amount    Money               `gorm:"type:bigint;not null" json:"amount"`
+	Status    DepositIntentStatus `gorm:"index;not null" json:"status"`
+	DepositID *uint               `json:"depositId,omitempty"`
+	ExpiresAt time.Time           `json:"expiresAt"`
+	CreatedAt time.Time           `json:"createdAt"`
+	UpdatedAt time.Time           `json:"updatedAt"`
+}
@@ -9,7 +9,7 @@ import (
 type Game struct {
 	ID           uint   `gorm:"primaryKey"`
 	Stake        int    // 10, 20, 50, 100
-	Status       string // waiting | in_progress | finished
+	Status       string // waiting | in_progress | finished | voided
 	RoundNumber  int
 	NumbersDrawn []string `gorm:"type:json"` // store drawn numbers as JSON array
 	StartTime    time.Time
@@ -17,5 +17,14 @@ type Game struct {
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	NumbersJSON  datatypes.JSON // stores drawn numbers in DB
-
+	VoidReason   string         // why the round was voided, if it was
+	PayoutBps    int            // lobby settings in force when the round started
+	RakeBps      int
+	WinningJSON  datatypes.JSON // winners with the pattern, cells and ball they won with
+	// Provably fair draw: the commitment published before the round, the
+	// player seeds mixed in, and the server seed, kept out of responses
+	// until the verify endpoint reveals it after the game is over
+	ServerSeedHash string
+	ClientSeed     string
+	ServerSeed     string `json:"-"`
 }
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+type LimitKind string
+
+const (
+	DepositLimit LimitKind = "deposit" // money credited to the wallet
+	LossLimit    LimitKind = "loss"    // stakes minus winnings and refunds
+	RoundsLimit  LimitKind = "rounds"  // rounds played
+)
+
+type LimitPeriod string
+
+const (
+	DailyLimit   LimitPeriod = "daily"
+	WeeklyLimit  LimitPeriod = "weekly"
+	MonthlyLimit LimitPeriod = "monthly"
+)
+
+// GamingLimit is a responsible-gaming cap a player set on themselves. Value
+// is santim for deposit and loss limits and a count for rounds. Lowering a
+// limit applies at once; raising or removing it is parked in Pending until
+// PendingFrom, so a player can't lift a limit in the heat of the moment.
+type GamingLimit struct {
+	ID          uint        `gorm:"primaryKey" json:"-"`
+	UserID      uint        `gorm:"uniqueIndex:idx_gaming_limit;not null" json:"-"`
+	Kind        LimitKind   `gorm:"uniqueIndex:idx_gaming_limit;size:16;not null" json:"kind"`
+	Period      LimitPeriod `gorm:"uniqueIndex:idx_gaming_limit;size:16;not null" json:"period"`
+	Value       int64       `gorm:"not null" json:"value"`
+	Pending     *int64      `json:"pending,omitempty"` // 0 means the limit is being removed
+	PendingFrom *time.Time  `json:"pendingFrom,omitempty"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	UpdatedAt   time.Time   `json:"updatedAt"`
+}
+
+// Start returns the beginning of the period containing t: midnight, the
+// Monday of the week, or the first of the month.
+func (p LimitPeriod) Start(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch p {
+	case WeeklyLimit:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case MonthlyLimit:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+	return day
+}
+
+func (p LimitPeriod) Valid() bool {
+	return p == DailyLimit || p == WeeklyLimit || p == MonthlyLimit
+}
+
+func (k LimitKind) Valid() bool {
+	return k == DepositLimit || k == LossLimit || k == RoundsLimit
+}
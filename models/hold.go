@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type HoldStatus string
+
+const (
+	HoldActive   HoldStatus = "active"   // stake reserved, card selected
+	HoldReleased HoldStatus = "released" // card deselected or round never started
+	HoldCaptured HoldStatus = "captured" // stake moved into a game pot
+)
+
+// StakeHold reserves one stake in escrow while a player holds a card before
+// the round starts.
+type StakeHold struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"userId"`
+	Stake     int        `gorm:"index;not null" json:"stake"` // lobby the card belongs to
+	CardID    int        `gorm:"not null" json:"cardId"`
+	Amount    Money      `gorm:"type:bigint;not null" json:"amount"`
+	Bonus     Money      `gorm:"type:bigint;not null;default:0" json:"bonus"` // part of Amount paid from bonus money
+	Status    HoldStatus `gorm:"index;not null" json:"status"`
+	GameID    *uint      `gorm:"index" json:"gameId,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// IdempotencyKey remembers the response to a money-moving request so a
+// retried request with the same key is answered without running it again.
+type IdempotencyKey struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	Scope        string    `gorm:"uniqueIndex:idx_idempotency_scope_key;not null" json:"scope"` // e.g. "POST /api/withdraw"
+	Key          string    `gorm:"uniqueIndex:idx_idempotency_scope_key;size:255;not null" json:"key"`
+	RequestHash  string    `gorm:"size:64;not null" json:"requestHash"` // sha256 of the request body
+	StatusCode   int       `json:"statusCode"`
+	ResponseBody []byte    `json:"-"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+type AccountType string
+
+const (
+	WalletAccount            AccountType = "wallet"             // player cash wallet
+	BonusWalletAccount       AccountType = "bonus_wallet"       // player bonus money, not withdrawable
+	HouseRakeAccount         AccountType = "house_rake"         // operator revenue
+	RoundPotAccount          AccountType = "round_pot"          // stakes collected for a single game
+	EscrowAccount            AccountType = "escrow"             // stakes held for selected cards
+	PendingWithdrawalAccount AccountType = "pending_withdrawal" // cash-outs waiting to be paid
+	ExternalAccount          AccountType = "external"           // money entering/leaving the platform
+	EquityAccount            AccountType = "equity"             // opening balances
+	JackpotAccount           AccountType = "jackpot"            // progressive jackpot of one stake lobby
+)
+
+// LedgerAccount holds money in the double-entry ledger. Balance is a cached
+// sum of every posting against the account.
+type LedgerAccount struct {
+	ID        uint        `gorm:"primaryKey" json:"id"`
+	Code      string      `gorm:"uniqueIndex;not null" json:"code"` // e.g. wallet:12, pot:game:7, house:rake
+	Type      AccountType `gorm:"index;not null" json:"type"`
+	UserID    *uint       `gorm:"index" json:"userId,omitempty"`
+	GameID    *uint       `gorm:"index" json:"gameId,omitempty"`
+	Balance   Money       `gorm:"type:bigint;not null;default:0" json:"balance"`
+	Currency  string      `gorm:"size:3;not null;default:ETB" json:"currency"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+}
+
+type EntryKind string
+
+const (
+	OpeningBalanceEntry   EntryKind = "opening_balance"
+	DepositEntry          EntryKind = "deposit"
+	WithdrawalEntry       EntryKind = "withdrawal"
+	WithdrawalPaidEntry   EntryKind = "withdrawal_paid"
+	WithdrawalReturnEntry EntryKind = "withdrawal_return"
+	StakeHoldEntry        EntryKind = "stake_hold"
+	StakeReleaseEntry     EntryKind = "stake_release"
+	StakeEntry            EntryKind = "stake"
+	PayoutEntry           EntryKind = "payout"
+	RakeEntry             EntryKind = "rake"
+	RefundEntry           EntryKind = "refund"
+	RakeReversalEntry     EntryKind = "rake_reversal" // rake handed back when a round is voided
+	SubsidyEntry          EntryKind = "subsidy"       // house tops up a pot to the guaranteed prize
+	JackpotFeedEntry      EntryKind = "jackpot_feed"  // slice of a round's stakes added to the jackpot
+	JackpotPayoutEntry    EntryKind = "jackpot_payout"
+	JackpotReturnEntry    EntryKind = "jackpot_return" // feed handed back when a round is voided
+	BonusGrantEntry       EntryKind = "bonus_grant"
+	BonusConversionEntry  EntryKind = "bonus_conversion" // wagering met, bonus becomes cash
+	BonusForfeitEntry     EntryKind = "bonus_forfeit"    // bonus expired unused
+	ReferralRewardEntry   EntryKind = "referral_reward"
+)
+
+// JournalEntry groups postings that move money between accounts. The
+// postings of an entry always sum to zero.
+type JournalEntry struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	Kind      EntryKind       `gorm:"index;not null" json:"kind"`
+	UserID    *uint           `gorm:"index" json:"userId,omitempty"`
+	GameID    *uint           `gorm:"index" json:"gameId,omitempty"`
+	Reference string          `gorm:"index" json:"reference,omitempty"`
+	Memo      string          `json:"memo,omitempty"`
+	Postings  []LedgerPosting `gorm:"foreignKey:EntryID" json:"postings,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// LedgerPosting is one leg of a journal entry. Amount is signed: positive
+// increases the account balance, negative decreases it.
+type LedgerPosting struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	EntryID   uint      `gorm:"index;not null" json:"entryId"`
+	AccountID uint      `gorm:"index;not null" json:"accountId"`
+	Amount    Money     `gorm:"type:bigint;not null" json:"amount"`
+	CreatedAt time.Time `json:"createdAt"`
+}
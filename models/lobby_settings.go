@@ -0,0 +1,112 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// LobbySettings holds the money rules for one stake lobby. Shares are in
+// basis points of the stakes collected in a round.
+type LobbySettings struct {
+	ID        uint  `gorm:"primaryKey" json:"-"`
+	Stake     int   `gorm:"uniqueIndex;not null" json:"stake"`
+	PayoutBps int   `gorm:"not null" json:"payoutBps"`                       // winner's share
+	RakeBps   int   `gorm:"not null" json:"rakeBps"`                         // house share, booked when the round starts
+	MinPot    Money `gorm:"type:bigint;not null;default:0" json:"minPot"`    // guaranteed prize, 0 for none
+	MaxPayout Money `gorm:"type:bigint;not null;default:0" json:"maxPayout"` // prize cap, 0 for none
+	// After the first valid claim the draw pauses for this long; every valid
+	// claim made in the window shares the prize
+	ClaimWindowMS int           `gorm:"not null;default:2000" json:"claimWindowMs"`
+	SplitRounding SplitRounding `gorm:"size:16;not null;default:house" json:"splitRounding"`
+	// Progressive jackpot, off while either is 0: JackpotBps of every round's
+	// stakes feeds it, and a full card within JackpotMaxBalls draws wins it
+	JackpotBps      int `gorm:"not null;default:0" json:"jackpotBps"`
+	JackpotMaxBalls int `gorm:"not null;default:0" json:"jackpotMaxBalls"`
+	// Names of the library patterns a claim can win with; empty for the
+	// classic set
+	Patterns []string `gorm:"serializer:json" json:"patterns"`
+	// Cards one player can hold in a round, each paying the stake
+	MaxCards  int       `gorm:"not null;default:1" json:"maxCards"`
+	UpdatedBy string    `json:"updatedBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SplitRounding decides who gets the santim left over when a prize doesn't
+// divide evenly between winners.
+type SplitRounding string
+
+const (
+	SplitRoundingHouse      SplitRounding = "house"       // remainder stays in the pot and is swept as rake
+	SplitRoundingFirstClaim SplitRounding = "first_claim" // one extra santim each to the earliest claims
+)
+
+// DefaultLobbySettings are used for lobbies nobody has configured: the
+// winner takes 80% and the house 20%.
+func DefaultLobbySettings(stake int) LobbySettings {
+	return LobbySettings{
+		Stake:         stake,
+		PayoutBps:     8000,
+		RakeBps:       2000,
+		ClaimWindowMS: 2000,
+		SplitRounding: SplitRoundingHouse,
+		MaxCards:      1,
+	}
+}
+
+// MaxCardsPerPlayer is the highest MaxCards a lobby can be set to.
+const MaxCardsPerPlayer = 4
+
+var ErrInvalidLobbySettings = errors.New("invalid lobby settings")
+
+func (s LobbySettings) Validate() error {
+	switch {
+	case s.PayoutBps < 0 || s.RakeBps < 0 || s.JackpotBps < 0:
+		return errors.Join(ErrInvalidLobbySettings, errors.New("shares can't be negative"))
+	case s.PayoutBps+s.RakeBps+s.JackpotBps > 10000:
+		return errors.Join(ErrInvalidLobbySettings, errors.New("payout, rake and jackpot can't exceed 100%"))
+	case s.JackpotMaxBalls < 0 || s.JackpotMaxBalls > 75:
+		return errors.Join(ErrInvalidLobbySettings, errors.New("jackpotMaxBalls must be between 0 and 75"))
+	case s.MinPot < 0 || s.MaxPayout < 0:
+		return errors.Join(ErrInvalidLobbySettings, errors.New("amounts can't be negative"))
+	case s.MaxPayout > 0 && s.MinPot > s.MaxPayout:
+		return errors.Join(ErrInvalidLobbySettings, errors.New("minPot can't exceed maxPayout"))
+	case s.ClaimWindowMS < 0 || s.ClaimWindowMS > 30000:
+		return errors.Join(ErrInvalidLobbySettings, errors.New("claimWindowMs must be between 0 and 30000"))
+	case s.SplitRounding != SplitRoundingHouse && s.SplitRounding != SplitRoundingFirstClaim:
+		return errors.Join(ErrInvalidLobbySettings, errors.New("splitRounding must be house or first_claim"))
+	case s.MaxCards < 1 || s.MaxCards > MaxCardsPerPlayer:
+		return errors.Join(ErrInvalidLobbySettings, fmt.Errorf("maxCards must be between 1 and %d", MaxCardsPerPlayer))
+	}
+	return nil
+}
+
+// Prize is what the winner of a round with these stakes is paid.
+func (s LobbySettings) Prize(stakes Money) Money {
+	prize := stakes.Percent(int64(s.PayoutBps))
+	if prize < s.MinPot {
+		prize = s.MinPot
+	}
+	if s.MaxPayout > 0 && prize > s.MaxPayout {
+		prize = s.MaxPayout
+	}
+	return prize
+}
+
+// SplitPrize divides a prize between n winners, in claim order.
+func (s LobbySettings) SplitPrize(prize Money, n int) []Money {
+	if s.SplitRounding == SplitRoundingFirstClaim {
+		return prize.Split(n)
+	}
+	shares := make([]Money, n)
+	for i := range shares {
+		shares[i] = prize / Money(n)
+	}
+	return shares
+}
+
+// JackpotOn reports whether the lobby runs a progressive jackpot.
+func (s LobbySettings) JackpotOn() bool {
+	return s.JackpotBps > 0 && s.JackpotMaxBalls > 0
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Currency is the ISO 4217 code every Money amount is held in.
+const Currency = "ETB"
+
+// SantimPerBirr is the number of minor units in one birr.
+const SantimPerBirr = 100
+
+// Money is an exact amount in santim (1/100 birr). It is stored as a bigint
+// and travels over JSON as a decimal birr value with two places, e.g. 12.50.
+type Money int64
+
+var ErrInvalidMoney = errors.New("invalid money amount")
+
+// Birr converts a whole birr amount to Money.
+func Birr(birr int64) Money {
+	return Money(birr * SantimPerBirr)
+}
+
+// Mul returns m multiplied by n, e.g. a stake times the number of players.
+func (m Money) Mul(n int) Money {
+	return m * Money(n)
+}
+
+// Percent returns bps basis points (1/100 of a percent) of m, truncated
+// toward zero to whole santim. Callers that split a pot take the truncated
+// share first and book the remainder elsewhere so no santim is lost.
+func (m Money) Percent(bps int64) Money {
+	return m * Money(bps) / 10000
+}
+
+// Split divides m into n shares that differ by at most one santim. The
+// leftover santim go to the first shares, so the parts always add up to m.
+func (m Money) Split(n int) []Money {
+	if n <= 0 {
+		return nil
+	}
+	share, rem := m/Money(n), m%Money(n)
+	out := make([]Money, n)
+	for i := range out {
+		out[i] = share
+		if Money(i) < rem {
+			out[i]++
+		}
+	}
+	return out
+}
+
+// Decimal formats m as birr with exactly two decimal places.
+func (m Money) Decimal() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/SantimPerBirr, v%SantimPerBirr)
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + Currency
+}
+
+// ParseMoney parses a decimal birr amount such as "12", "12.5" or "12.50".
+// More than two decimal places is rejected rather than rounded.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	// Only the leading minus is a sign; strconv would also take "+" and a
+	// sign inside either part
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" || len(frac) > 2 || !digits(whole) || !digits(frac) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+
+	birr, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+	santim, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil || santim < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+
+	v := Money(birr*SantimPerBirr + santim)
+	if neg {
+		v = -v
+	}
+	return v, nil
+}
+
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.Decimal()), nil
+}
+
+// UnmarshalJSON accepts either a JSON number or a string holding a decimal
+// birr amount.
+func (m *Money) UnmarshalJSON(b []byte) error {
+	b = bytes.Trim(b, `"`)
+	if string(b) == "null" || len(b) == 0 {
+		return nil
+	}
+	v, err := ParseMoney(string(b))
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Value stores Money as a plain bigint of santim.
+func (m Money) Value() (driver.Value, error) {
+	return int64(m), nil
+}
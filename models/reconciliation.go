@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// ReconciliationReport is the stored result of matching a bank or
+// mobile-money statement against our deposits.
+type ReconciliationReport struct {
+	ID                 uint           `gorm:"primaryKey" json:"id"`
+	Source             string         `json:"source"` // uploaded file name
+	Provider           string         `json:"provider,omitempty"`
+	PeriodStart        *time.Time     `json:"periodStart,omitempty"`
+	PeriodEnd          *time.Time     `json:"periodEnd,omitempty"`
+	Matched            int            `json:"matched"`
+	AmountMismatch     int            `json:"amountMismatch"`
+	UnmatchedStatement int            `json:"unmatchedStatement"` // on the statement, not in our DB
+	UnmatchedDB        int            `json:"unmatchedDb"`        // in our DB, not on the statement
+	Entries            datatypes.JSON `json:"entries,omitempty"`
+	CreatedBy          string         `json:"createdBy"`
+	CreatedAt          time.Time      `json:"createdAt"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type ReferralStatus string
+
+const (
+	ReferralPending  ReferralStatus = "pending"  // waiting for a deposit and enough rounds
+	ReferralRewarded ReferralStatus = "rewarded" // referrer has been paid
+	ReferralRejected ReferralStatus = "rejected" // self-referral or shared phone
+)
+
+// Referral links a player to the player whose invite link they arrived
+// through. Each player can be referred once.
+type Referral struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	ReferrerID uint           `gorm:"index;not null" json:"referrerId"`
+	ReferredID uint           `gorm:"uniqueIndex;not null" json:"referredId"`
+	Code       string         `gorm:"size:32;not null" json:"code"` // start parameter used
+	Status     ReferralStatus `gorm:"index;size:16;not null" json:"status"`
+	Reason     string         `json:"reason,omitempty"` // why it was rejected
+	Reward     Money          `gorm:"type:bigint;not null;default:0" json:"reward"`
+	RewardedAt *time.Time     `json:"rewardedAt,omitempty"`
+	CreatedAt  time.Time      `json:"createdAt"`
+	UpdatedAt  time.Time      `json:"updatedAt"`
+}
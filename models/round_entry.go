@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+type RoundEntryStatus string
+
+const (
+	EntryPaid     RoundEntryStatus = "paid"     // stake is in the game pot
+	EntryWon      RoundEntryStatus = "won"      // card won the round
+	EntryLost     RoundEntryStatus = "lost"     // round settled without this card winning
+	EntryRefunded RoundEntryStatus = "refunded" // round voided, stake returned
+)
+
+// RoundEntry records a paid card in a game so the stake can be refunded if
+// the round never settles.
+type RoundEntry struct {
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	GameID    uint             `gorm:"index;not null" json:"gameId"`
+	UserID    uint             `gorm:"index;not null" json:"userId"`
+	CardID    int              `json:"cardId"`
+	HoldID    uint             `gorm:"uniqueIndex" json:"holdId"`
+	Stake     Money            `gorm:"type:bigint;not null" json:"stake"`
+	Bonus     Money            `gorm:"type:bigint;not null;default:0" json:"bonus"` // part of Stake paid from bonus money
+	Status    RoundEntryStatus `gorm:"index;not null" json:"status"`
+	CreatedAt time.Time        `json:"createdAt"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+}
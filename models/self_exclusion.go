@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// SelfExclusion keeps a player out of play until EndsAt, or for good when
+// EndsAt is nil. Players can't cut an exclusion short.
+type SelfExclusion struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"userId"`
+	Period    string     `gorm:"size:16;not null" json:"period"` // 24h, 7d, 30d or permanent
+	StartsAt  time.Time  `json:"startsAt"`
+	EndsAt    *time.Time `gorm:"index" json:"endsAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
@@ -7,13 +7,36 @@ type TransactionType string
 const (
 	DepositTransaction  TransactionType = "deposit"
 	WithdrawTransaction TransactionType = "withdraw"
+	// Returned to the wallet after a withdrawal was rejected or cancelled
+	WithdrawReturnTransaction TransactionType = "withdraw_return"
+	RefundTransaction         TransactionType = "refund"
+	StakeTransaction          TransactionType = "stake"
+	WinTransaction            TransactionType = "win"
+	JackpotTransaction        TransactionType = "jackpot"
+	BonusTransaction          TransactionType = "bonus"     // bonus money converted to cash
+	BonusWinTransaction       TransactionType = "bonus_win" // winnings paid as bonus money
+	ReferralTransaction       TransactionType = "referral"
 )
 
+// IsDebit reports whether this kind of transaction takes money out of the
+// wallet. Amounts are stored positive; the type gives the direction.
+func (t TransactionType) IsDebit() bool {
+	return t == WithdrawTransaction || t == StakeTransaction
+}
+
+// Transaction is the player-facing history line for a wallet movement. The
+// journal entry it mirrors is the source of truth. Wallet says which of the
+// player's wallets moved, cash or bonus, and BalanceAfter is that wallet's
+// balance.
 type Transaction struct {
 	ID           uint            `gorm:"primaryKey" json:"id"`
-	UserID       uint            `json:"user_id"`
-	Type         TransactionType `json:"type"`
-	Amount       float64         `json:"amount"`
-	BalanceAfter float64         `json:"balance_after"`
-	CreatedAt    time.Time       `json:"created_at"`
+	UserID       uint            `gorm:"index:idx_transactions_user_created,priority:1" json:"user_id"`
+	Type         TransactionType `gorm:"index" json:"type"`
+	Amount       Money           `gorm:"type:bigint" json:"amount"`
+	Wallet       AccountType     `gorm:"size:32;not null;default:wallet" json:"wallet"`
+	BalanceAfter Money           `gorm:"type:bigint" json:"balance_after"`
+	Currency     string          `gorm:"size:3;not null;default:ETB" json:"currency"`
+	GameID       *uint           `json:"game_id,omitempty"`
+	Reference    string          `json:"reference,omitempty"`
+	CreatedAt    time.Time       `gorm:"index:idx_transactions_user_created,priority:2" json:"created_at"`
 }
@@ -3,11 +3,16 @@ package models
 import "time"
 
 type User struct {
-	ID         uint      `gorm:"primaryKey" json:"id"`
-	TelegramID int64     `gorm:"uniqueIndex" json:"telegram_id"`
-	Name       string    `json:"username"`
-	Phone      string    `json:"phone"`
-	Balance    float64   `json:"balance"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	TelegramID int64  `gorm:"uniqueIndex" json:"telegram_id"`
+	Name       string `json:"username"`
+	Phone      string `json:"phone"`
+	Balance    Money  `gorm:"type:bigint;not null;default:0" json:"balance"` // santim
+	// Bonus money, kept apart from withdrawable cash
+	BonusBalance Money  `gorm:"type:bigint;not null;default:0" json:"bonus_balance"`
+	Currency     string `gorm:"size:3;not null;default:ETB" json:"currency"`
+	// Code others use in the bot's start link to credit this player
+	ReferralCode *string   `gorm:"uniqueIndex;size:32" json:"referral_code,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// VoucherBatch is a run of voucher codes generated together, e.g. for one
+// agent's scratch cards. Every code in a batch has the same amount, expiry
+// and usage limit.
+type VoucherBatch struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `json:"name"`
+	Amount    Money     `gorm:"type:bigint;not null" json:"amount"`
+	Count     int       `gorm:"not null" json:"count"`
+	MaxUses   int       `gorm:"not null" json:"maxUses"` // redemptions allowed per code
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Voucher is one redeemable code. Code is stored normalised: upper case,
+// without the dashes it is printed with.
+type Voucher struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	BatchID   uint      `gorm:"index;not null" json:"batchId"`
+	Code      string    `gorm:"uniqueIndex;size:32;not null" json:"code"`
+	Amount    Money     `gorm:"type:bigint;not null" json:"amount"`
+	MaxUses   int       `gorm:"not null" json:"maxUses"`
+	Uses      int       `gorm:"not null;default:0" json:"uses"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// VoucherRedemption links a redeemed voucher to the deposit it credited.
+// A player can redeem each code once.
+type VoucherRedemption struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	VoucherID uint      `gorm:"uniqueIndex:idx_voucher_redemptions_voucher_user;not null" json:"voucherId"`
+	UserID    uint      `gorm:"uniqueIndex:idx_voucher_redemptions_voucher_user;index;not null" json:"userId"`
+	DepositID uint      `gorm:"not null" json:"depositId"`
+	Amount    Money     `gorm:"type:bigint;not null" json:"amount"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type VoucherResult string
+
+const (
+	VoucherRedeemed VoucherResult = "redeemed"
+	VoucherInvalid  VoucherResult = "invalid" // no such code
+	VoucherExpired  VoucherResult = "expired"
+	VoucherUsedUp   VoucherResult = "used_up"  // reached its usage limit
+	VoucherRepeated VoucherResult = "repeated" // this player already redeemed it
+	VoucherRefused  VoucherResult = "refused"  // deposit limit or self-exclusion
+	VoucherLocked   VoucherResult = "locked"   // too many failed attempts
+)
+
+// VoucherAttempt is the audit line for every redemption attempt, successful
+// or not. Failed attempts also drive the brute-force lockout.
+type VoucherAttempt struct {
+	ID        uint          `gorm:"primaryKey" json:"id"`
+	UserID    uint          `gorm:"index:idx_voucher_attempts_user_created,priority:1" json:"userId"`
+	Code      string        `gorm:"size:64" json:"code"` // as entered, normalised
+	VoucherID *uint         `gorm:"index" json:"voucherId,omitempty"`
+	Result    VoucherResult `gorm:"index;size:16;not null" json:"result"`
+	IP        string        `gorm:"index;size:64" json:"ip"`
+	CreatedAt time.Time     `gorm:"index:idx_voucher_attempts_user_created,priority:2" json:"createdAt"`
+}
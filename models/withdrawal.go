@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type WithdrawalStatus string
+
+const (
+	WithdrawalRequested WithdrawalStatus = "requested" // funds held, waiting for review
+	WithdrawalApproved  WithdrawalStatus = "approved"  // cleared for payout
+	WithdrawalPaid      WithdrawalStatus = "paid"      // money sent to the player
+	WithdrawalRejected  WithdrawalStatus = "rejected"  // refused by an operator, funds returned
+	WithdrawalCancelled WithdrawalStatus = "cancelled" // withdrawn by the player, funds returned
+)
+
+// WithdrawalRequest tracks a cash-out from request to payout. The amount sits
+// in the pending withdrawals account until the request is paid or returned.
+type WithdrawalRequest struct {
+	ID              uint             `gorm:"primaryKey" json:"id"`
+	UserID          uint             `gorm:"index;not null" json:"userId"`
+	Amount          Money            `gorm:"type:bigint;not null" json:"amount"`
+	Currency        string           `gorm:"size:3;not null;default:ETB" json:"currency"`
+	Method          string           `json:"method"`  // e.g. telebirr, cbe
+	Account         string           `json:"account"` // phone or account number to pay
+	Status          WithdrawalStatus `gorm:"index;not null" json:"status"`
+	PayoutReference string           `json:"payoutReference,omitempty"`
+	Reason          string           `json:"reason,omitempty"` // why it was rejected
+	ReviewedBy      string           `json:"reviewedBy,omitempty"`
+	ApprovedAt      *time.Time       `json:"approvedAt,omitempty"`
+	ResolvedAt      *time.Time       `json:"resolvedAt,omitempty"` // paid, rejected or cancelled
+	CreatedAt       time.Time        `json:"createdAt"`
+	UpdatedAt       time.Time        `json:"updatedAt"`
+}
@@ -0,0 +1,50 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/models"
+)
+
+// FileVerifier is a fake provider backed by a JSON file of payments keyed by
+// reference. The file is re-read on every call so tests and staging can add
+// payments without a restart:
+//
+//	{"TX123": {"amount": "150.00", "payer": "Abebe", "paidAt": "2024-01-02T15:04:05Z"}}
+type FileVerifier struct {
+	Path string
+}
+
+func (v *FileVerifier) Name() string { return "fake" }
+
+func (v *FileVerifier) Verify(_ context.Context, reference string) (*VerifiedDeposit, error) {
+	data, err := os.ReadFile(v.Path)
+	if err != nil {
+		return nil, fmt.Errorf("read fake payments: %w", err)
+	}
+
+	var payments map[string]struct {
+		Amount models.Money `json:"amount"`
+		Payer  string       `json:"payer"`
+		PaidAt time.Time    `json:"paidAt"`
+	}
+	if err := json.Unmarshal(data, &payments); err != nil {
+		return nil, fmt.Errorf("parse fake payments: %w", err)
+	}
+
+	p, ok := payments[reference]
+	if !ok {
+		return nil, ErrReferenceNotFound
+	}
+	return &VerifiedDeposit{
+		Provider:  v.Name(),
+		Reference: reference,
+		Amount:    p.Amount,
+		Payer:     p.Payer,
+		PaidAt:    p.PaidAt,
+	}, nil
+}
@@ -0,0 +1,127 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/models"
+)
+
+// MobileMoneyVerifier looks up a wallet-to-merchant transfer by its
+// transaction ID:
+//
+//	GET {BaseURL}/transactions/{reference}
+//	-> {"transactionId": "...", "amount": "150.00", "payer": "2519...", "paidAt": RFC3339, "status": "completed"}
+type MobileMoneyVerifier struct {
+	BaseURL string
+	APIKey  string
+	Client  *http.Client
+}
+
+func (v *MobileMoneyVerifier) Name() string { return "mobile_money" }
+
+func (v *MobileMoneyVerifier) Verify(ctx context.Context, reference string) (*VerifiedDeposit, error) {
+	endpoint := strings.TrimRight(v.BaseURL, "/") + "/transactions/" + url.PathEscape(reference)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var body struct {
+		TransactionID string       `json:"transactionId"`
+		Amount        models.Money `json:"amount"`
+		Payer         string       `json:"payer"`
+		PaidAt        time.Time    `json:"paidAt"`
+		Status        string       `json:"status"`
+	}
+	if err := doJSON(v.Client, req, v.APIKey, &body); err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(body.Status, "completed") {
+		return nil, fmt.Errorf("%w: transaction %s is %s", ErrReferenceNotFound, reference, body.Status)
+	}
+
+	return &VerifiedDeposit{
+		Provider:  v.Name(),
+		Reference: body.TransactionID,
+		Amount:    body.Amount,
+		Payer:     body.Payer,
+		PaidAt:    body.PaidAt,
+	}, nil
+}
+
+// BankVerifier confirms an incoming bank transfer:
+//
+//	POST {BaseURL}/verify {"reference": "..."}
+//	-> {"reference": "...", "amount": "150.00", "senderName": "...", "senderAccount": "...", "valueDate": RFC3339}
+type BankVerifier struct {
+	BaseURL string
+	APIKey  string
+	Client  *http.Client
+}
+
+func (v *BankVerifier) Name() string { return "bank" }
+
+func (v *BankVerifier) Verify(ctx context.Context, reference string) (*VerifiedDeposit, error) {
+	payload, _ := json.Marshal(map[string]string{"reference": reference})
+	endpoint := strings.TrimRight(v.BaseURL, "/") + "/verify"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var body struct {
+		Reference     string       `json:"reference"`
+		Amount        models.Money `json:"amount"`
+		SenderName    string       `json:"senderName"`
+		SenderAccount string       `json:"senderAccount"`
+		ValueDate     time.Time    `json:"valueDate"`
+	}
+	if err := doJSON(v.Client, req, v.APIKey, &body); err != nil {
+		return nil, err
+	}
+
+	payer := body.SenderName
+	if body.SenderAccount != "" {
+		payer = strings.TrimSpace(payer + " " + body.SenderAccount)
+	}
+	return &VerifiedDeposit{
+		Provider:  v.Name(),
+		Reference: body.Reference,
+		Amount:    body.Amount,
+		Payer:     payer,
+		PaidAt:    body.ValueDate,
+	}, nil
+}
+
+// doJSON sends req with the provider API key and decodes a JSON response.
+// A 404 from the provider means the reference doesn't exist.
+func doJSON(client *http.Client, req *http.Request, apiKey string, out any) error {
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrReferenceNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("provider returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
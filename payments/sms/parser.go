@@ -0,0 +1,123 @@
+// Package sms reads the payment confirmation messages players forward from
+// their phones and pulls out the fields needed to credit a deposit.
+package sms
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/models"
+)
+
+var ErrUnrecognized = errors.New("sms is not a known payment receipt")
+
+// Ethiopian providers stamp receipts in East Africa Time.
+var eat = time.FixedZone("EAT", 3*60*60)
+
+// Receipt is the data extracted from one payment SMS.
+type Receipt struct {
+	Format        string       `json:"format"`   // which receipt format matched
+	Verifier      string       `json:"verifier"` // payments provider that can confirm it
+	TransactionID string       `json:"transactionId"`
+	Amount        models.Money `json:"amount"`
+	Sender        string       `json:"sender"`
+	Time          time.Time    `json:"time"`
+}
+
+// format describes one provider's receipt. The pattern must define the
+// named groups amount, txid and time; sender is optional.
+type format struct {
+	name     string
+	verifier string
+	pattern  *regexp.Regexp
+	layouts  []string
+}
+
+var formats = []format{
+	{
+		// Dear Abebe Kebede, You have transferred ETB 150.00 to Bingo Ethiopia (2519****5678)
+		// on 12/01/2024 14:23:05. Your transaction number is CAB1XYZ89Q. ...
+		name:     "telebirr",
+		verifier: "mobile_money",
+		pattern: regexp.MustCompile(`(?is)Dear\s+(?P<sender>[^,]+),\s*You have (?:transferred|paid)\s+ETB\s*(?P<amount>[\d,]+(?:\.\d{1,2})?)` +
+			`.*?\bon\s+(?P<time>\d{2}/\d{2}/\d{4}\s+\d{2}:\d{2}:\d{2}).*?transaction number is\s+(?P<txid>[A-Z0-9]+)`),
+		layouts: []string{"02/01/2006 15:04:05"},
+	},
+	{
+		// Dear Abebe, You have sent 150.00 Br. to 0911223344 on 12/01/24 14:23, Txn ID BX12345678.
+		// Your CBE Birr balance is 1,250.00 Br.
+		name:     "cbe_birr",
+		verifier: "mobile_money",
+		pattern: regexp.MustCompile(`(?is)Dear\s+(?P<sender>[^,]+),\s*You have sent\s+(?P<amount>[\d,]+(?:\.\d{1,2})?)\s*Br\.?` +
+			`.*?\bon\s+(?P<time>\d{2}/\d{2}/\d{2}\s+\d{2}:\d{2}),?\s*Txn ID\s+(?P<txid>[A-Z0-9]+)`),
+		layouts: []string{"02/01/06 15:04"},
+	},
+	{
+		// BHX7ABC123 Confirmed. ETB150.00 sent to BINGO ET 0700123456 on 12/1/24 at 2:23 PM
+		// from 0711987654. New M-PESA balance is ETB1,250.00.
+		name:     "mpesa",
+		verifier: "mobile_money",
+		pattern: regexp.MustCompile(`(?is)^\s*(?P<txid>[A-Z0-9]{10})\s+Confirmed\.\s*ETB\s*(?P<amount>[\d,]+(?:\.\d{1,2})?)\s+sent to` +
+			`.*?\bon\s+(?P<time>\d{1,2}/\d{1,2}/\d{2}\s+at\s+\d{1,2}:\d{2}\s*[AP]M)(?:\s+from\s+(?P<sender>\d+))?`),
+		layouts: []string{"2/1/06 at 3:04 PM", "2/1/06 at 3:04PM"},
+	},
+	{
+		// Dear Abebe your Account 1000*****1234 has been debited with ETB 150.00 on 12-Jan-2024
+		// at 14:23:05 ... Ref No FT24012ABCDE ...
+		name:     "cbe",
+		verifier: "bank",
+		pattern: regexp.MustCompile(`(?is)Dear\s+(?P<sender>.+?)\s+your Account\s+\S+\s+has been debited with\s+ETB\s*(?P<amount>[\d,]+(?:\.\d{1,2})?)` +
+			`.*?\bon\s+(?P<time>\d{2}-[A-Za-z]{3}-\d{4}\s+at\s+\d{2}:\d{2}:\d{2}).*?Ref No\.?\s+(?P<txid>[A-Z0-9]+)`),
+		layouts: []string{"02-Jan-2006 at 15:04:05"},
+	},
+}
+
+// Parse tries every known receipt format and returns the first match.
+func Parse(text string) (*Receipt, error) {
+	text = strings.TrimSpace(text)
+	for _, f := range formats {
+		m := f.pattern.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		return f.build(m)
+	}
+	return nil, ErrUnrecognized
+}
+
+func (f format) build(m []string) (*Receipt, error) {
+	group := func(name string) string {
+		if i := f.pattern.SubexpIndex(name); i >= 0 {
+			return strings.TrimSpace(m[i])
+		}
+		return ""
+	}
+
+	amount, err := models.ParseMoney(strings.ReplaceAll(group("amount"), ",", ""))
+	if err != nil {
+		return nil, fmt.Errorf("%s receipt: %w", f.name, err)
+	}
+
+	raw := strings.Join(strings.Fields(group("time")), " ")
+	var at time.Time
+	for _, layout := range f.layouts {
+		if at, err = time.ParseInLocation(layout, raw, eat); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s receipt: bad time %q", f.name, raw)
+	}
+
+	return &Receipt{
+		Format:        f.name,
+		Verifier:      f.verifier,
+		TransactionID: group("txid"),
+		Amount:        amount,
+		Sender:        group("sender"),
+		Time:          at,
+	}, nil
+}
@@ -0,0 +1,72 @@
+package sms
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the .golden files in testdata")
+
+// Each testdata/*.txt is a forwarded SMS. Its .golden file holds the parsed
+// receipt as JSON, or "error: ..." when the SMS must be rejected.
+func TestParseGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no fixtures in testdata")
+	}
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".txt")
+		t.Run(name, func(t *testing.T) {
+			text, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := parseResult(t, string(text))
+
+			golden := strings.TrimSuffix(input, ".txt") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("Parse(%s) =\n%s\nwant\n%s", input, got, want)
+			}
+			if rejected := strings.HasPrefix(got, "error: "); rejected != strings.HasPrefix(name, "reject_") {
+				t.Errorf("fixture %s: rejected = %v, but its name says otherwise", name, rejected)
+			}
+		})
+	}
+}
+
+func parseResult(t *testing.T, text string) string {
+	t.Helper()
+	receipt, err := Parse(text)
+	if err != nil {
+		return "error: " + err.Error() + "\n"
+	}
+	out, err := json.MarshalIndent(receipt, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out) + "\n"
+}
+
+func TestParseFormatsCovered(t *testing.T) {
+	for _, f := range formats {
+		if _, err := os.Stat(filepath.Join("testdata", f.name+".txt")); err != nil {
+			t.Errorf("format %s has no fixture: %v", f.name, err)
+		}
+	}
+}
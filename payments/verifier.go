@@ -0,0 +1,113 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/models"
+)
+
+var (
+	ErrReferenceNotFound = errors.New("payment reference not found")
+	ErrUnknownProvider   = errors.New("unknown deposit provider")
+)
+
+// VerifiedDeposit is what a payment provider reports for a reference.
+type VerifiedDeposit struct {
+	Provider  string
+	Reference string
+	Amount    models.Money
+	Payer     string
+	PaidAt    time.Time
+}
+
+// DepositVerifier checks a payment reference against a provider and reports
+// the money that actually arrived.
+type DepositVerifier interface {
+	Name() string
+	Verify(ctx context.Context, reference string) (*VerifiedDeposit, error)
+}
+
+var (
+	verifiersMu     sync.RWMutex
+	verifiers       = make(map[string]DepositVerifier)
+	defaultProvider string
+)
+
+// Register makes a verifier available by its name. The first one registered
+// becomes the default unless DEFAULT_DEPOSIT_PROVIDER says otherwise.
+func Register(v DepositVerifier) {
+	verifiersMu.Lock()
+	defer verifiersMu.Unlock()
+	verifiers[v.Name()] = v
+	if defaultProvider == "" {
+		defaultProvider = v.Name()
+	}
+}
+
+// Lookup returns the verifier for a provider name, or the default one when
+// name is empty.
+func Lookup(name string) (DepositVerifier, error) {
+	verifiersMu.RLock()
+	defer verifiersMu.RUnlock()
+	if name == "" {
+		name = defaultProvider
+	}
+	v, ok := verifiers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, name)
+	}
+	return v, nil
+}
+
+// Providers lists the registered provider names.
+func Providers() []string {
+	verifiersMu.RLock()
+	defer verifiersMu.RUnlock()
+	names := make([]string, 0, len(verifiers))
+	for name := range verifiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetupVerifiers registers the providers configured in the environment:
+//
+//	MOBILE_MONEY_VERIFY_URL / MOBILE_MONEY_API_KEY  mobile-money adapter
+//	BANK_VERIFY_URL / BANK_API_KEY                  bank transfer adapter
+//	FAKE_PAYMENTS_FILE                              JSON file provider for tests
+//	DEFAULT_DEPOSIT_PROVIDER                        provider used when a request names none
+func SetupVerifiers() {
+	client := &http.Client{Timeout: 15 * time.Second}
+
+	if url := os.Getenv("MOBILE_MONEY_VERIFY_URL"); url != "" {
+		Register(&MobileMoneyVerifier{BaseURL: url, APIKey: os.Getenv("MOBILE_MONEY_API_KEY"), Client: client})
+	}
+	if url := os.Getenv("BANK_VERIFY_URL"); url != "" {
+		Register(&BankVerifier{BaseURL: url, APIKey: os.Getenv("BANK_API_KEY"), Client: client})
+	}
+	if path := os.Getenv("FAKE_PAYMENTS_FILE"); path != "" {
+		Register(&FileVerifier{Path: path})
+	}
+
+	if name := os.Getenv("DEFAULT_DEPOSIT_PROVIDER"); name != "" {
+		verifiersMu.Lock()
+		defaultProvider = name
+		verifiersMu.Unlock()
+	}
+
+	if len(Providers()) == 0 {
+		log.Println("[Payments] no deposit providers configured, deposit verification is disabled")
+		return
+	}
+	log.Printf("[Payments] deposit providers: %s (default %s)", strings.Join(Providers(), ", "), defaultProvider)
+}
@@ -0,0 +1,224 @@
+package reconcile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/models"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type DriftStatus string
+
+const (
+	Drifted  DriftStatus = "drifted"  // users.balance differs from the wallet's postings
+	Unbooked DriftStatus = "unbooked" // users.balance set, but the ledger has no wallet yet
+)
+
+// Drift is one player whose stored balance doesn't match the ledger.
+// Breakdown sums the wallet's postings by journal entry kind (deposit,
+// withdrawal, stake_hold, payout, ...), so support can see where the money
+// went.
+type Drift struct {
+	Status    DriftStatus                       `json:"status"`
+	UserID    uint                              `json:"userId"`
+	Balance   models.Money                      `json:"balance"`  // users.balance
+	Cached    models.Money                      `json:"cached"`   // ledger_accounts.balance
+	Expected  models.Money                      `json:"expected"` // sum of postings
+	Diff      models.Money                      `json:"diff"`     // balance - expected
+	Breakdown map[models.EntryKind]models.Money `json:"breakdown,omitempty"`
+}
+
+// CheckBalances recomputes every player's balance from their wallet
+// postings and reports the ones that don't match.
+func CheckBalances(db *gorm.DB) (*models.BalanceReport, []Drift, error) {
+	report := &models.BalanceReport{StartedAt: time.Now()}
+
+	var rows []struct {
+		UserID   uint
+		Balance  models.Money
+		WalletID *uint
+		Cached   models.Money
+		Expected models.Money
+	}
+	err := db.Table("users").
+		Select(`users.id AS user_id, users.balance, a.id AS wallet_id,
+			COALESCE(a.balance, 0) AS cached,
+			COALESCE((SELECT SUM(p.amount) FROM ledger_postings p WHERE p.account_id = a.id), 0) AS expected`).
+		Joins("LEFT JOIN ledger_accounts a ON a.user_id = users.id AND a.type = ?", models.WalletAccount).
+		Order("users.id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var drifts []Drift
+	for _, r := range rows {
+		report.Users++
+		d := Drift{UserID: r.UserID, Balance: r.Balance, Cached: r.Cached, Expected: r.Expected, Diff: r.Balance - r.Expected}
+		switch {
+		case r.WalletID == nil && r.Balance != 0:
+			// Booked as an opening balance the first time the wallet is used
+			d.Status = Unbooked
+			report.Unbooked++
+		case r.WalletID != nil && (r.Balance != r.Expected || r.Cached != r.Expected):
+			d.Status = Drifted
+			report.Drifted++
+			report.TotalDrift += abs(d.Diff)
+			if d.Breakdown, err = breakdown(db, *r.WalletID); err != nil {
+				return nil, nil, err
+			}
+		default:
+			continue
+		}
+		drifts = append(drifts, d)
+	}
+
+	b, err := json.Marshal(drifts)
+	if err != nil {
+		return nil, nil, err
+	}
+	report.Entries = datatypes.JSON(b)
+	return report, drifts, nil
+}
+
+func breakdown(db *gorm.DB, walletID uint) (map[models.EntryKind]models.Money, error) {
+	var sums []struct {
+		Kind   models.EntryKind
+		Amount models.Money
+	}
+	err := db.Table("ledger_postings p").
+		Select("e.kind, SUM(p.amount) AS amount").
+		Joins("JOIN journal_entries e ON e.id = p.entry_id").
+		Where("p.account_id = ?", walletID).
+		Group("e.kind").
+		Scan(&sums).Error
+	out := make(map[models.EntryKind]models.Money, len(sums))
+	for _, s := range sums {
+		out[s.Kind] = s.Amount
+	}
+	return out, err
+}
+
+// Drifts decodes the lines stored on a balance report.
+func Drifts(report *models.BalanceReport) ([]Drift, error) {
+	var drifts []Drift
+	if len(report.Entries) == 0 {
+		return drifts, nil
+	}
+	err := json.Unmarshal(report.Entries, &drifts)
+	return drifts, err
+}
+
+// LatestBalanceReport returns the most recent balance check, or nil when
+// none has run yet.
+func LatestBalanceReport(db *gorm.DB) (*models.BalanceReport, error) {
+	var reports []models.BalanceReport
+	if err := db.Omit("entries").Order("id DESC").Limit(1).Find(&reports).Error; err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return nil, nil
+	}
+	return &reports[0], nil
+}
+
+// StartBalanceJob runs the balance check every night at BALANCE_CHECK_HOUR
+// (server local time, default 3). Drift is logged as an alert and, when
+// BALANCE_ALERT_WEBHOOK_URL is set, posted there as JSON.
+func StartBalanceJob(db *gorm.DB) {
+	hour := 3
+	if v := os.Getenv("BALANCE_CHECK_HOUR"); v != "" {
+		h, err := strconv.Atoi(v)
+		if err != nil || h < 0 || h > 23 {
+			log.Printf("[WARN] BALANCE_CHECK_HOUR=%q is not an hour, using %d", v, hour)
+		} else {
+			hour = h
+		}
+	}
+	webhook := os.Getenv("BALANCE_ALERT_WEBHOOK_URL")
+
+	go func() {
+		for {
+			time.Sleep(time.Until(nextRun(time.Now(), hour)))
+			if _, err := RunBalanceCheck(db, "job", webhook); err != nil {
+				log.Printf("[ERROR] Nightly balance check failed: %v", err)
+			}
+		}
+	}()
+	log.Printf("[Init] Nightly balance check scheduled at %02d:00", hour)
+}
+
+// RunBalanceCheck checks every balance, stores the report and raises an
+// alert when anything drifted.
+func RunBalanceCheck(db *gorm.DB, by, webhook string) (*models.BalanceReport, error) {
+	report, drifts, err := CheckBalances(db)
+	if err != nil {
+		return nil, err
+	}
+	report.CreatedBy = by
+	if err := db.Create(report).Error; err != nil {
+		return nil, err
+	}
+
+	if report.Drifted == 0 {
+		log.Printf("[Balances] report %d: %d users, no drift", report.ID, report.Users)
+		return report, nil
+	}
+	log.Printf("[ALERT] Balance drift: report %d found %d users off by %s in total", report.ID, report.Drifted, report.TotalDrift)
+	for _, d := range drifts {
+		if d.Status == Drifted {
+			log.Printf("[ALERT]   user %d: balance %s, ledger %s (diff %s)", d.UserID, d.Balance, d.Expected, d.Diff)
+		}
+	}
+	if webhook != "" {
+		if err := postAlert(webhook, report); err != nil {
+			log.Printf("[ERROR] Failed to send balance drift alert: %v", err)
+		}
+	}
+	return report, nil
+}
+
+func postAlert(url string, report *models.BalanceReport) error {
+	body, err := json.Marshal(map[string]any{
+		"text":       fmt.Sprintf("Balance drift: %d users off by %s (report %d)", report.Drifted, report.TotalDrift, report.ID),
+		"reportId":   report.ID,
+		"drifted":    report.Drifted,
+		"totalDrift": report.TotalDrift,
+	})
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func nextRun(now time.Time, hour int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+func abs(m models.Money) models.Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
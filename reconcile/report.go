@@ -0,0 +1,215 @@
+package reconcile
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/models"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type EntryStatus string
+
+const (
+	Matched            EntryStatus = "matched"
+	AmountMismatch     EntryStatus = "amount_mismatch"
+	UnmatchedStatement EntryStatus = "unmatched_statement" // on the statement, not in our DB
+	UnmatchedDB        EntryStatus = "unmatched_db"        // in our DB, not on the statement
+)
+
+// Entry is one line of a reconciliation report.
+type Entry struct {
+	Status          EntryStatus  `json:"status"`
+	Reference       string       `json:"reference"`
+	StatementAmount models.Money `json:"statementAmount"`
+	DepositAmount   models.Money `json:"depositAmount"`
+	DepositID       uint         `json:"depositId,omitempty"`
+	UserID          uint         `json:"userId,omitempty"`
+	Line            int          `json:"line,omitempty"`
+	Date            *time.Time   `json:"date,omitempty"`
+	Payer           string       `json:"payer,omitempty"`
+}
+
+// Options narrows which deposits the statement is compared against.
+type Options struct {
+	Source   string
+	Provider string     // only deposits from this provider, if set
+	From, To *time.Time // defaults to the statement's date range
+}
+
+// Run matches statement rows to deposits by reference and builds a report.
+// Deposits are only reported as missing from the statement when they fall
+// inside the statement period, so a partial statement doesn't flag the
+// whole table, and never when they were paid with a voucher.
+func Run(db *gorm.DB, rows []Row, opts Options) (*models.ReconciliationReport, []Entry, error) {
+	from, to := opts.From, opts.To
+	for _, r := range rows {
+		if r.Date == nil {
+			continue
+		}
+		if opts.From == nil && (from == nil || r.Date.Before(*from)) {
+			from = r.Date
+		}
+		if opts.To == nil && (to == nil || r.Date.After(*to)) {
+			to = r.Date
+		}
+	}
+
+	refs := make([]string, 0, len(rows))
+	for _, r := range rows {
+		refs = append(refs, r.Reference)
+	}
+
+	// Deposits named on the statement, whatever their date
+	var named []models.Deposit
+	if len(refs) > 0 {
+		if err := db.Where("reference IN ?", refs).Find(&named).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Deposits recorded during the statement period
+	q := db.Model(&models.Deposit{}).Where("provider <> ?", models.VoucherProvider)
+	if opts.Provider != "" {
+		q = q.Where("provider = ?", opts.Provider)
+	}
+	if from != nil {
+		q = q.Where("created_at >= ?", *from)
+	}
+	if to != nil {
+		// Statement dates are often day-precision; include the whole last day
+		q = q.Where("created_at < ?", to.Add(24*time.Hour))
+	}
+	var inPeriod []models.Deposit
+	if err := q.Find(&inPeriod).Error; err != nil {
+		return nil, nil, err
+	}
+
+	report := &models.ReconciliationReport{
+		Source:      opts.Source,
+		Provider:    opts.Provider,
+		PeriodStart: from,
+		PeriodEnd:   to,
+	}
+	entries := match(report, rows, append(named, inPeriod...), opts.Provider)
+	b, err := json.Marshal(entries)
+	if err != nil {
+		return nil, nil, err
+	}
+	report.Entries = datatypes.JSON(b)
+	return report, entries, nil
+}
+
+// match pairs statement rows with deposits by reference and counts the
+// outcomes on report. Deposits that aren't on the statement are reported
+// missing from it, unless they were paid with a voucher, or a provider is
+// given and they came from another.
+func match(report *models.ReconciliationReport, rows []Row, deposits []models.Deposit, provider string) []Entry {
+	byRef := make(map[string]models.Deposit, len(deposits))
+	for _, d := range deposits {
+		byRef[d.Reference] = d
+	}
+
+	var entries []Entry
+	seen := make(map[string]bool, len(rows))
+	for _, r := range rows {
+		seen[r.Reference] = true
+		e := Entry{Reference: r.Reference, StatementAmount: r.Amount, Line: r.Line, Date: r.Date, Payer: r.Payer}
+		d, ok := byRef[r.Reference]
+		switch {
+		case !ok:
+			e.Status = UnmatchedStatement
+			report.UnmatchedStatement++
+		case d.Amount != r.Amount:
+			e.Status = AmountMismatch
+			report.AmountMismatch++
+		default:
+			e.Status = Matched
+			report.Matched++
+		}
+		if ok {
+			e.DepositID, e.UserID, e.DepositAmount = d.ID, d.UserID, d.Amount
+		}
+		entries = append(entries, e)
+	}
+
+	for _, d := range deposits {
+		if seen[d.Reference] || d.Provider == models.VoucherProvider || (provider != "" && d.Provider != provider) {
+			continue
+		}
+		seen[d.Reference] = true
+		created := d.CreatedAt
+		entries = append(entries, Entry{
+			Status:        UnmatchedDB,
+			Reference:     d.Reference,
+			DepositAmount: d.Amount,
+			DepositID:     d.ID,
+			UserID:        d.UserID,
+			Date:          &created,
+			Payer:         d.Payer,
+		})
+		report.UnmatchedDB++
+	}
+
+	// Problems first, then by reference
+	sort.SliceStable(entries, func(i, j int) bool {
+		if (entries[i].Status == Matched) != (entries[j].Status == Matched) {
+			return entries[j].Status == Matched
+		}
+		return entries[i].Reference < entries[j].Reference
+	})
+
+	return entries
+}
+
+// Entries decodes the lines stored on a report.
+func Entries(report *models.ReconciliationReport) ([]Entry, error) {
+	var entries []Entry
+	if len(report.Entries) == 0 {
+		return entries, nil
+	}
+	err := json.Unmarshal(report.Entries, &entries)
+	return entries, err
+}
+
+// WriteCSV writes report lines in a spreadsheet-friendly form.
+func WriteCSV(w io.Writer, entries []Entry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"status", "reference", "statement_amount", "deposit_amount", "deposit_id", "user_id", "line", "date", "payer"}); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		date := ""
+		if e.Date != nil {
+			date = e.Date.Format(time.RFC3339)
+		}
+		rec := []string{
+			string(e.Status),
+			e.Reference,
+			e.StatementAmount.Decimal(),
+			e.DepositAmount.Decimal(),
+			optionalID(e.DepositID),
+			optionalID(e.UserID),
+			optionalID(uint(e.Line)),
+			date,
+			e.Payer,
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func optionalID(id uint) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(id), 10)
+}
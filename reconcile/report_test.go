@@ -0,0 +1,105 @@
+package reconcile
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/models"
+)
+
+func deposit(id uint, ref string, amount models.Money, provider string) models.Deposit {
+	d := models.Deposit{UserID: 100 + id, Reference: ref, Amount: amount, Provider: provider}
+	d.ID = id
+	d.CreatedAt = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return d
+}
+
+func TestMatch(t *testing.T) {
+	type outcome struct {
+		Status    EntryStatus
+		Reference string
+		DepositID uint
+	}
+	tests := []struct {
+		name     string
+		rows     []Row
+		deposits []models.Deposit
+		provider string
+		want     []outcome // problems first, then by reference
+		counts   [4]int    // matched, mismatched, unmatched statement, unmatched db
+	}{
+		{
+			name:     "matched",
+			rows:     []Row{{Line: 2, Reference: "TX1", Amount: 15000}},
+			deposits: []models.Deposit{deposit(1, "TX1", 15000, "mobile_money")},
+			want:     []outcome{{Matched, "TX1", 1}},
+			counts:   [4]int{1, 0, 0, 0},
+		},
+		{
+			name:     "amount mismatch",
+			rows:     []Row{{Line: 2, Reference: "TX1", Amount: 15000}},
+			deposits: []models.Deposit{deposit(1, "TX1", 10000, "mobile_money")},
+			want:     []outcome{{AmountMismatch, "TX1", 1}},
+			counts:   [4]int{0, 1, 0, 0},
+		},
+		{
+			name:   "on the statement only",
+			rows:   []Row{{Line: 2, Reference: "TX9", Amount: 500}},
+			want:   []outcome{{UnmatchedStatement, "TX9", 0}},
+			counts: [4]int{0, 0, 1, 0},
+		},
+		{
+			name:     "missing from the statement",
+			rows:     []Row{{Line: 2, Reference: "TX1", Amount: 15000}},
+			deposits: []models.Deposit{deposit(1, "TX1", 15000, "mobile_money"), deposit(2, "TX2", 700, "mobile_money")},
+			want:     []outcome{{UnmatchedDB, "TX2", 2}, {Matched, "TX1", 1}},
+			counts:   [4]int{1, 0, 0, 1},
+		},
+		{
+			name: "a deposit found twice is reported once",
+			deposits: []models.Deposit{
+				deposit(2, "TX2", 700, "mobile_money"), deposit(2, "TX2", 700, "mobile_money"),
+			},
+			want:   []outcome{{UnmatchedDB, "TX2", 2}},
+			counts: [4]int{0, 0, 0, 1},
+		},
+		{
+			name:     "vouchers are never on a statement",
+			rows:     []Row{{Line: 2, Reference: "TX1", Amount: 15000}},
+			deposits: []models.Deposit{deposit(1, "TX1", 15000, "mobile_money"), deposit(2, "voucher:7:102", 2000, models.VoucherProvider)},
+			want:     []outcome{{Matched, "TX1", 1}},
+			counts:   [4]int{1, 0, 0, 0},
+		},
+		{
+			name: "provider filter",
+			rows: []Row{{Line: 2, Reference: "FT1", Amount: 50000}},
+			deposits: []models.Deposit{
+				deposit(1, "FT1", 50000, "mobile_money"), // on the statement, so matched whatever its provider
+				deposit(2, "FT2", 800, "bank"),
+				deposit(3, "TX3", 900, "mobile_money"),
+			},
+			provider: "bank",
+			want:     []outcome{{UnmatchedDB, "FT2", 2}, {Matched, "FT1", 1}},
+			counts:   [4]int{1, 0, 0, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &models.ReconciliationReport{}
+			entries := match(report, tt.rows, tt.deposits, tt.provider)
+
+			got := make([]outcome, len(entries))
+			for i, e := range entries {
+				got[i] = outcome{e.Status, e.Reference, e.DepositID}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("entries = %v, want %v", got, tt.want)
+			}
+			counts := [4]int{report.Matched, report.AmountMismatch, report.UnmatchedStatement, report.UnmatchedDB}
+			if counts != tt.counts {
+				t.Errorf("counts = %v, want %v", counts, tt.counts)
+			}
+		})
+	}
+}
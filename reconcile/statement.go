@@ -0,0 +1,143 @@
+// Package reconcile matches bank and mobile-money statements against the
+// deposits recorded in our database, and player balances against the
+// ledger.
+package reconcile
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/models"
+)
+
+// Mapping tells the importer which statement columns hold which field.
+// Column names are matched against the header row, case-insensitively.
+type Mapping struct {
+	Reference  string `json:"reference"`
+	Amount     string `json:"amount"`
+	Date       string `json:"date,omitempty"`
+	Payer      string `json:"payer,omitempty"`
+	DateLayout string `json:"dateLayout,omitempty"` // Go time layout, defaults to 2006-01-02
+	Delimiter  rune   `json:"delimiter,omitempty"`  // defaults to ','
+	SkipRows   int    `json:"skipRows,omitempty"`   // banner lines before the header
+}
+
+// Presets are the statement layouts we receive regularly.
+var Presets = map[string]Mapping{
+	"telebirr": {Reference: "Transaction ID", Amount: "Amount", Date: "Transaction Time", Payer: "Opposite Party", DateLayout: "2006-01-02 15:04:05"},
+	"cbe":      {Reference: "Reference", Amount: "Credit", Date: "Date", Payer: "Narrative", DateLayout: "02/01/2006"},
+	"generic":  {Reference: "reference", Amount: "amount", Date: "date", Payer: "payer"},
+}
+
+// Row is one credit line from a statement.
+type Row struct {
+	Line      int          `json:"line"`
+	Reference string       `json:"reference"`
+	Amount    models.Money `json:"amount"`
+	Date      *time.Time   `json:"date,omitempty"`
+	Payer     string       `json:"payer,omitempty"`
+}
+
+// ParseStatement reads statement rows using m. Rows with an empty reference
+// or amount (totals, debits) are skipped.
+func ParseStatement(r io.Reader, m Mapping) ([]Row, error) {
+	if m.Reference == "" || m.Amount == "" {
+		return nil, errors.New("mapping needs reference and amount columns")
+	}
+	if m.DateLayout == "" {
+		m.DateLayout = "2006-01-02"
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	if m.Delimiter != 0 {
+		cr.Comma = m.Delimiter
+	}
+
+	for i := 0; i < m.SkipRows; i++ {
+		if _, err := cr.Read(); err != nil {
+			return nil, fmt.Errorf("skip row %d: %w", i+1, err)
+		}
+	}
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+	}
+	col := func(name string) (int, error) {
+		if name == "" {
+			return -1, nil
+		}
+		i, ok := index[strings.ToLower(name)]
+		if !ok {
+			return -1, fmt.Errorf("column %q not found in header", name)
+		}
+		return i, nil
+	}
+
+	refCol, err := col(m.Reference)
+	if err != nil {
+		return nil, err
+	}
+	amountCol, err := col(m.Amount)
+	if err != nil {
+		return nil, err
+	}
+	dateCol, err := col(m.Date)
+	if err != nil {
+		return nil, err
+	}
+	payerCol, err := col(m.Payer)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []Row
+	line := m.SkipRows + 1
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		field := func(i int) string {
+			if i < 0 || i >= len(rec) {
+				return ""
+			}
+			return strings.TrimSpace(rec[i])
+		}
+
+		ref, rawAmount := field(refCol), field(amountCol)
+		if ref == "" || rawAmount == "" {
+			continue
+		}
+		amount, err := models.ParseMoney(strings.ReplaceAll(rawAmount, ",", ""))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		row := Row{Line: line, Reference: ref, Amount: amount, Payer: field(payerCol)}
+		if raw := field(dateCol); raw != "" {
+			d, err := time.Parse(m.DateLayout, raw)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: bad date %q", line, raw)
+			}
+			row.Date = &d
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,101 @@
+package reconcile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(s string) *time.Time {
+	d, err := time.Parse("2006-01-02 15:04:05", s)
+	if err != nil {
+		panic(err)
+	}
+	return &d
+}
+
+func TestParseStatement(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping Mapping
+		csv     string
+		want    []Row
+	}{
+		{
+			name:    "generic",
+			mapping: Presets["generic"],
+			csv:     "reference,amount,date,payer\nTX1,150,2026-03-01,0911234567\nTX2,12.5,2026-03-02,\n",
+			want: []Row{
+				{Line: 2, Reference: "TX1", Amount: 15000, Date: date("2026-03-01 00:00:00"), Payer: "0911234567"},
+				{Line: 3, Reference: "TX2", Amount: 1250, Date: date("2026-03-02 00:00:00")},
+			},
+		},
+		{
+			name:    "quoted fields with commas, thousands separators and a BOM",
+			mapping: Presets["cbe"],
+			csv: "\ufeffDate,Reference,Narrative,Debit,Credit\n" +
+				`02/03/2026,FT261,"KEBEDE, ABEBE",,"1,500.00"` + "\n" +
+				`03/03/2026,FT262,"ATM ""WITHDRAWAL""",200.00,` + "\n",
+			want: []Row{
+				{Line: 2, Reference: "FT261", Amount: 150000, Date: date("2026-03-02 00:00:00"), Payer: "KEBEDE, ABEBE"},
+			},
+		},
+		{
+			name:    "telebirr timestamps and header case",
+			mapping: Presets["telebirr"],
+			csv:     "TRANSACTION ID,amount,Transaction Time,Opposite Party\n CG81 , 99.99 ,2026-03-01 09:30:00,251911234567\n",
+			want: []Row{
+				{Line: 2, Reference: "CG81", Amount: 9999, Date: date("2026-03-01 09:30:00"), Payer: "251911234567"},
+			},
+		},
+		{
+			name:    "banner rows, semicolons and short rows",
+			mapping: Mapping{Reference: "ref", Amount: "amt", Delimiter: ';', SkipRows: 2},
+			csv:     "Statement for March\nAccount 1000123\nref;amt\nA1;10\nTotal\n;30\n",
+			want:    []Row{{Line: 4, Reference: "A1", Amount: 1000}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseStatement(strings.NewReader(tt.csv), tt.mapping)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows:\n got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStatementRejects(t *testing.T) {
+	generic := Presets["generic"]
+	minimal := Mapping{Reference: "reference", Amount: "amount", Date: "date"}
+	tests := []struct {
+		name    string
+		mapping Mapping
+		csv     string
+		errHas  string
+	}{
+		{"no reference column in mapping", Mapping{Amount: "amount"}, "amount\n1\n", "mapping needs"},
+		{"column missing from header", generic, "reference,value\nTX1,1\n", `column "amount"`},
+		{"empty file", generic, "", "read header"},
+		{"too few banner rows", Mapping{Reference: "r", Amount: "a", SkipRows: 3}, "x\n", "skip row 2"},
+		{"amount with three decimals", minimal, "reference,amount,date\nTX1,1.005,\n", "line 2"},
+		{"amount with a currency", minimal, "reference,amount,date\nTX1,ETB 5,\n", "line 2"},
+		{"bad date", minimal, "reference,amount,date\nTX1,5,01/03/2026\n", `bad date "01/03/2026"`},
+		{"unterminated quote", minimal, "reference,amount,date\n\"TX1,5\n", "line"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ParseStatement(strings.NewReader(tt.csv), tt.mapping)
+			if err == nil {
+				t.Fatalf("accepted, rows %+v", rows)
+			}
+			if !strings.Contains(err.Error(), tt.errHas) {
+				t.Errorf("error %q does not mention %q", err, tt.errHas)
+			}
+		})
+	}
+}
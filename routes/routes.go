@@ -2,6 +2,7 @@ package routes
 
 import (
 	"github.com/bellapacxx/bingo-backend/controllers"
+	"github.com/bellapacxx/bingo-backend/middleware"
 	"github.com/bellapacxx/bingo-backend/services"
 	"github.com/gin-gonic/gin"
 )
@@ -23,19 +24,68 @@ func SetupRoutes(r *gin.Engine) {
 	api.GET("/games/:id", controllers.GetGame)           // Get single game info
 	api.POST("/games/:id/join", controllers.JoinGame)    // Join a game
 	api.GET("/games/:id/lobby", controllers.LobbyStatus) // Get lobby status
+	api.GET("/games/:id/verify", controllers.VerifyDraw) // Reveal seeds and recompute the draw
 
 	// ----------------------
 	// Card/Ticket routes
 	// ----------------------
-	api.POST("/tickets", controllers.BuyTicket)                         // Buy bingo card/ticket
-	api.GET("/tickets/user/:telegram_id", controllers.GetTicketsByUser) // Get user's tickets
+	api.POST("/tickets", middleware.Idempotent(), controllers.BuyTicket) // Buy bingo card/ticket
+	api.GET("/tickets/user/:telegram_id", controllers.GetTicketsByUser)  // Get user's tickets
 
 	// ----------------------
 	// Transaction routes
 	// ----------------------
-	api.POST("/deposit", controllers.Deposit)   // Deposit funds
-	api.POST("/withdraw", controllers.Withdraw) // Withdraw funds
-    api.POST("/deposit/verify", controllers.VerifyDeposit) 
+	// Money-moving POSTs require an Idempotency-Key header
+	api.POST("/deposit", middleware.Idempotent(), controllers.Deposit)                         // Deprecated: same as /deposit/verify
+	api.POST("/withdraw", middleware.Idempotent(), controllers.Withdraw)                       // Withdraw funds
+	api.POST("/deposit/verify", middleware.Idempotent(), controllers.VerifyDeposit)            // Verify and credit a deposit
+	api.POST("/deposit/intents", controllers.CreateDepositIntent)                              // Announce an upcoming payment
+	api.POST("/deposit/sms", middleware.Idempotent(), controllers.ConfirmSMSDeposit)           // Credit a forwarded SMS receipt
+	api.POST("/vouchers/redeem", middleware.Idempotent(), controllers.RedeemVoucher)           // Redeem a scratch voucher
+	api.GET("/users/:telegram_id/withdrawals", controllers.ListUserWithdrawals)                // Player's cash-outs
+	api.GET("/users/:telegram_id/transactions", controllers.ListUserTransactions)              // Wallet history with totals
+	api.GET("/users/:telegram_id/limits", controllers.GetLimits)                               // Responsible-gaming limits
+	api.PUT("/users/:telegram_id/limits", controllers.SetLimit)                                // Set, lower or raise a limit
+	api.GET("/users/:telegram_id/self-exclusion", controllers.GetSelfExclusion)                // Running self-exclusion
+	api.POST("/users/:telegram_id/self-exclusion", controllers.SelfExclude)                    // Exclude yourself from play
+	api.GET("/users/:telegram_id/bonus", controllers.GetBonus)                                 // Bonus balance and wagering progress
+	api.GET("/users/:telegram_id/referrals", controllers.GetReferrals)                         // Invite link and referral stats
+	api.POST("/withdrawals/:id/cancel", middleware.Idempotent(), controllers.CancelWithdrawal) // Player cancels a request
+
+	// ----------------------
+	// Admin routes (X-Admin-Token)
+	// ----------------------
+	admin := api.Group("/admin", middleware.AdminAuth())
+	admin.GET("/withdrawals", controllers.AdminListWithdrawals)
+	admin.POST("/withdrawals/:id/approve", middleware.Idempotent(), controllers.ApproveWithdrawal)
+	admin.POST("/withdrawals/:id/reject", middleware.Idempotent(), controllers.RejectWithdrawal)
+	admin.POST("/withdrawals/:id/paid", middleware.Idempotent(), controllers.MarkWithdrawalPaid)
+	admin.GET("/deposits", controllers.AdminListDeposits)
+	admin.POST("/deposits/:id/resolve", middleware.Idempotent(), controllers.ResolveDeposit)
+	admin.POST("/reconciliations", controllers.UploadStatement)
+	admin.GET("/reconciliations", controllers.ListReconciliations)
+	admin.GET("/reconciliations/:id", controllers.GetReconciliation)
+	admin.GET("/reconciliations/:id/download", controllers.DownloadReconciliation)
+	admin.POST("/games/:id/void", middleware.Idempotent(), controllers.VoidGame)
+	admin.GET("/lobbies/settings", controllers.ListLobbySettings)
+	admin.PUT("/lobbies/:stake/settings", controllers.UpdateLobbySettings)
+	admin.PUT("/lobbies/:stake/next-round-patterns", controllers.SetNextRoundPatterns)
+	admin.GET("/patterns", controllers.ListPatterns)
+	admin.GET("/revenue/rounds", controllers.RoundRevenue)
+	admin.POST("/balance-reports", controllers.RunBalanceCheck)
+	admin.GET("/balance-reports", controllers.ListBalanceReports)
+	admin.GET("/balance-reports/:id", controllers.GetBalanceReport)
+	admin.GET("/exclusions", controllers.AdminListExclusions)
+	admin.POST("/promotions", controllers.SendPromotion)
+	admin.GET("/bonus-promotions", controllers.ListBonusPromotions)
+	admin.POST("/bonus-promotions", controllers.CreateBonusPromotion)
+	admin.POST("/users/:telegram_id/bonuses", middleware.Idempotent(), controllers.GrantBonus)
+	admin.GET("/referrals", controllers.AdminListReferrals)
+	admin.POST("/voucher-batches", controllers.CreateVoucherBatch)
+	admin.GET("/voucher-batches", controllers.ListVoucherBatches)
+	admin.GET("/voucher-batches/:id/export", controllers.ExportVoucherBatch)
+	admin.GET("/voucher-attempts", controllers.ListVoucherAttempts)
+
 	// ----------------------
 	// Lobby WebSocket
 	// ----------------------
@@ -44,7 +94,5 @@ func SetupRoutes(r *gin.Engine) {
 	// ----------------------
 	// Health check
 	// ----------------------
-	api.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+	api.GET("/health", controllers.Health)
 }
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidPromotion  = errors.New("invalid bonus promotion")
+	ErrPromotionInactive = errors.New("bonus promotion is not active")
+)
+
+// FundingOrder says which bucket pays for a stake first.
+type FundingOrder string
+
+const (
+	CashFirst  FundingOrder = "cash_first"
+	BonusFirst FundingOrder = "bonus_first"
+)
+
+// StakeFundingOrder reads STAKE_FUNDING_ORDER; cash is spent first unless
+// it is set to bonus_first.
+func StakeFundingOrder() FundingOrder {
+	if FundingOrder(strings.TrimSpace(os.Getenv("STAKE_FUNDING_ORDER"))) == BonusFirst {
+		return BonusFirst
+	}
+	return CashFirst
+}
+
+// BonusWalletAccount returns the ledger account holding a player's bonus
+// money. Its balance is mirrored in users.bonus_balance.
+func BonusWalletAccount(tx *gorm.DB, userID uint) (*models.LedgerAccount, error) {
+	acct, _, err := findOrCreateAccount(tx, models.LedgerAccount{
+		Code:   bonusCode(userID),
+		Type:   models.BonusWalletAccount,
+		UserID: &userID,
+	})
+	return acct, err
+}
+
+// splitStake decides how much of amount comes from cash and how much from
+// bonus money. Whatever the first bucket can't cover is taken from the
+// other; posting fails with ErrInsufficientFunds if both together can't.
+func splitStake(wallet, bonus *models.LedgerAccount, amount models.Money) (cash, fromBonus models.Money) {
+	if StakeFundingOrder() == BonusFirst {
+		fromBonus = min(max(bonus.Balance, 0), amount)
+		return amount - fromBonus, fromBonus
+	}
+	cash = min(max(wallet.Balance, 0), amount)
+	return cash, amount - cash
+}
+
+// bucketLegs builds the legs moving money between the player's cash and
+// bonus buckets and a house account; sign is -1 to take it from the player,
+// +1 to pay it to them.
+func bucketLegs(wallet, bonus, other *models.LedgerAccount, cash, fromBonus models.Money, sign models.Money) []Leg {
+	legs := []Leg{{Account: other, Amount: -sign * (cash + fromBonus)}}
+	if cash > 0 {
+		legs = append(legs, Leg{Account: wallet, Amount: sign * cash})
+	}
+	if fromBonus > 0 {
+		legs = append(legs, Leg{Account: bonus, Amount: sign * fromBonus})
+	}
+	return legs
+}
+
+// CreatePromotion stores a new bonus promotion.
+func CreatePromotion(p *models.BonusPromotion) error {
+	p.Code = strings.TrimSpace(p.Code)
+	if p.WinningsTo == "" {
+		p.WinningsTo = models.WinningsToBonus
+	}
+	switch {
+	case p.Code == "":
+		return fmt.Errorf("%w: code is required", ErrInvalidPromotion)
+	case p.WageringMultiplier < 0:
+		return fmt.Errorf("%w: wagering multiplier can't be negative", ErrInvalidPromotion)
+	case p.ValidDays <= 0:
+		return fmt.Errorf("%w: valid days must be positive", ErrInvalidPromotion)
+	case p.WinningsTo != models.WinningsToBonus && p.WinningsTo != models.WinningsToCash:
+		return fmt.Errorf("%w: winnings go to bonus or cash", ErrInvalidPromotion)
+	}
+	return config.DB.Create(p).Error
+}
+
+// GrantBonus credits amount of bonus money to a player under a promotion,
+// funded from PromotionsCode. The wagering requirement and expiry are fixed
+// from the promotion at the time of the grant.
+func GrantBonus(tx *gorm.DB, userID, promotionID uint, amount models.Money, by string) (*models.BonusGrant, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidPromotion)
+	}
+	var grant *models.BonusGrant
+	err := WalletTx(tx, func(tx *gorm.DB) error {
+		var promo models.BonusPromotion
+		if err := tx.First(&promo, promotionID).Error; err != nil {
+			return err
+		}
+		if !promo.Active {
+			return ErrPromotionInactive
+		}
+		grant = &models.BonusGrant{
+			UserID:           userID,
+			PromotionID:      promo.ID,
+			Amount:           amount,
+			WageringRequired: amount.Mul(promo.WageringMultiplier),
+			WinningsTo:       promo.WinningsTo,
+			Status:           models.BonusActive,
+			ExpiresAt:        time.Now().AddDate(0, 0, promo.ValidDays),
+			GrantedBy:        by,
+		}
+		if err := tx.Create(grant).Error; err != nil {
+			return err
+		}
+		funding, err := SystemAccount(tx, PromotionsCode, models.EquityAccount)
+		if err != nil {
+			return err
+		}
+		bonus, err := BonusWalletAccount(tx, userID)
+		if err != nil {
+			return err
+		}
+		entry := &models.JournalEntry{Kind: models.BonusGrantEntry, UserID: &userID, Reference: grantRef(grant.ID), Memo: promo.Code}
+		if err := Transfer(tx, entry, funding, bonus, amount); err != nil {
+			return err
+		}
+		// A grant with no wagering to do is cash straight away.
+		return settleBonus(tx, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
+
+// UserBonuses returns the player's grants, newest first.
+func UserBonuses(tx *gorm.DB, userID uint) ([]models.BonusGrant, error) {
+	var grants []models.BonusGrant
+	err := tx.Where("user_id = ?", userID).Order("id DESC").Find(&grants).Error
+	return grants, err
+}
+
+// activeGrants locks the player's active grants, oldest first.
+func activeGrants(tx *gorm.DB, userID uint) ([]models.BonusGrant, error) {
+	var grants []models.BonusGrant
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND status = ?", userID, models.BonusActive).
+		Order("id").Find(&grants).Error
+	return grants, err
+}
+
+// applyWagering counts a captured stake towards the player's active grants,
+// filling the oldest first, then converts the bonus once all are met.
+func applyWagering(tx *gorm.DB, userID uint, stake models.Money) error {
+	grants, err := activeGrants(tx, userID)
+	if err != nil || len(grants) == 0 {
+		return err
+	}
+	left := stake
+	for i := range grants {
+		g := &grants[i]
+		if left == 0 {
+			break
+		}
+		add := min(g.WageringRequired-g.Wagered, left)
+		if add <= 0 {
+			continue
+		}
+		g.Wagered += add
+		left -= add
+		if err := tx.Model(g).Update("wagered", g.Wagered).Error; err != nil {
+			return err
+		}
+	}
+	return settleBonus(tx, userID)
+}
+
+// settleBonus converts the whole bonus balance to cash once every active
+// grant has met its wagering requirement. Bonus money is one pool, so
+// nothing converts while any grant still has wagering left.
+func settleBonus(tx *gorm.DB, userID uint) error {
+	grants, err := activeGrants(tx, userID)
+	if err != nil || len(grants) == 0 {
+		return err
+	}
+	for _, g := range grants {
+		if g.Wagered < g.WageringRequired {
+			return nil
+		}
+	}
+
+	bonus, err := BonusWalletAccount(tx, userID)
+	if err != nil {
+		return err
+	}
+	if amount := bonus.Balance; amount > 0 {
+		wallet, err := WalletAccount(tx, userID)
+		if err != nil {
+			return err
+		}
+		entry := &models.JournalEntry{Kind: models.BonusConversionEntry, UserID: &userID, Reference: grantRef(grants[len(grants)-1].ID)}
+		if err := Transfer(tx, entry, bonus, wallet, amount); err != nil {
+			return err
+		}
+		if err := recordTransaction(tx, wallet, models.BonusTransaction, amount, nil, entry.Reference); err != nil {
+			return err
+		}
+	}
+	return resolveGrants(tx, grants, models.BonusConverted)
+}
+
+func resolveGrants(tx *gorm.DB, grants []models.BonusGrant, status models.BonusStatus) error {
+	ids := make([]uint, 0, len(grants))
+	for _, g := range grants {
+		ids = append(ids, g.ID)
+	}
+	return tx.Model(&models.BonusGrant{}).Where("id IN ?", ids).
+		Updates(map[string]any{"status": status, "resolved_at": time.Now()}).Error
+}
+
+// bonusWinningsRule is the rule for winnings on bonus-funded stakes: the
+// oldest active grant decides, and with no active grant left they are cash.
+func bonusWinningsRule(tx *gorm.DB, userID uint) (models.WinningsRule, error) {
+	var grant models.BonusGrant
+	err := tx.Where("user_id = ? AND status = ?", userID, models.BonusActive).Order("id").First(&grant).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.WinningsToCash, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return grant.WinningsTo, nil
+}
+
+// bonusShareOf returns the part of prize won with bonus-funded stakes,
+// in proportion to the bonus share of the winning card's stake.
+func bonusShareOf(tx *gorm.DB, gameID uint, cardID int, prize models.Money) (models.Money, error) {
+	var staked struct {
+		Stake models.Money
+		Bonus models.Money
+	}
+	if err := tx.Model(&models.RoundEntry{}).
+		Where("game_id = ? AND card_id = ?", gameID, cardID).
+		Select("COALESCE(SUM(stake), 0) AS stake, COALESCE(SUM(bonus), 0) AS bonus").
+		Scan(&staked).Error; err != nil {
+		return 0, err
+	}
+	if staked.Bonus == 0 || staked.Stake == 0 {
+		return 0, nil
+	}
+	return prize * staked.Bonus / staked.Stake, nil
+}
+
+// ExpireBonuses ends grants past their expiry date. If no active grant is
+// left the remaining bonus balance is forfeited; otherwise at most the
+// expired grants' amount is, and the rest waits for the other grants.
+func ExpireBonuses() (expired int, err error) {
+	var users []uint
+	if err := config.DB.Model(&models.BonusGrant{}).
+		Where("status = ? AND expires_at <= ?", models.BonusActive, time.Now()).
+		Distinct().Pluck("user_id", &users).Error; err != nil {
+		return 0, err
+	}
+	for _, userID := range users {
+		n, err := expireUserBonuses(userID)
+		if err != nil {
+			return expired, fmt.Errorf("user %d: %w", userID, err)
+		}
+		expired += n
+	}
+	return expired, nil
+}
+
+func expireUserBonuses(userID uint) (expired int, err error) {
+	err = WalletTx(config.DB, func(tx *gorm.DB) error {
+		grants, err := activeGrants(tx, userID)
+		if err != nil {
+			return err
+		}
+		var due []models.BonusGrant
+		var dueAmount models.Money
+		remaining := 0
+		now := time.Now()
+		for _, g := range grants {
+			if g.ExpiresAt.After(now) {
+				remaining++
+				continue
+			}
+			due = append(due, g)
+			dueAmount += g.Amount
+		}
+		if len(due) == 0 {
+			return nil
+		}
+		expired = len(due)
+
+		bonus, err := BonusWalletAccount(tx, userID)
+		if err != nil {
+			return err
+		}
+		forfeit := bonus.Balance
+		if remaining > 0 {
+			forfeit = min(forfeit, dueAmount)
+		}
+		if forfeit > 0 {
+			funding, err := SystemAccount(tx, PromotionsCode, models.EquityAccount)
+			if err != nil {
+				return err
+			}
+			entry := &models.JournalEntry{Kind: models.BonusForfeitEntry, UserID: &userID, Reference: grantRef(due[0].ID)}
+			if err := Transfer(tx, entry, bonus, funding, forfeit); err != nil {
+				return err
+			}
+		}
+		if err := resolveGrants(tx, due, models.BonusExpired); err != nil {
+			return err
+		}
+		// The grants that expired may have been all that held the rest back.
+		return settleBonus(tx, userID)
+	})
+	return expired, err
+}
+
+// StartBonusExpiryJob expires overdue grants at startup and then hourly.
+func StartBonusExpiryJob() {
+	go func() {
+		for {
+			if n, err := ExpireBonuses(); err != nil {
+				log.Printf("[Bonus] expiry failed: %v", err)
+			} else if n > 0 {
+				log.Printf("[Bonus] expired %d grants", n)
+			}
+			time.Sleep(time.Hour)
+		}
+	}()
+}
+
+func grantRef(id uint) string {
+	return fmt.Sprintf("bonus_grant:%d", id)
+}
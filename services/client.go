@@ -70,8 +70,15 @@ func (c *Client) readPump() {
 				} else {
 					log.Printf("[Client %d] failed to select card %d", c.userID, cardID)
 				}
+			case "deselect_card":
+				// Without a card_id every card is given up
+				c.lobby.DeselectCard(c.userID, optionalCardID(data))
+			case "client_seed":
+				seed, _ := data["seed"].(string)
+				c.lobby.AddClientSeed(c.userID, seed)
 			case "bingo":
-				c.lobby.CheckBingo(c.userID)
+				// Without a card_id every unchecked card is checked
+				c.lobby.CheckBingo(c.userID, optionalCardID(data))
 			default:
 				log.Printf("[Client %d] unknown action: %v", c.userID, data["action"])
 			}
@@ -79,6 +86,12 @@ func (c *Client) readPump() {
 	}
 }
 
+// optionalCardID returns the message's card_id, or 0 when it has none.
+func optionalCardID(data map[string]any) int {
+	cardID, _ := data["card_id"].(float64)
+	return int(cardID)
+}
+
 func (c *Client) writePump() {
 	defer c.conn.Close()
 	for msg := range c.send {
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/models"
+	"github.com/bellapacxx/bingo-backend/payments"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrDuplicateDeposit = errors.New("deposit with this reference already processed")
+	ErrDepositNotOpen   = errors.New("deposit is not disputed")
+)
+
+// RecordDeposit claims d.Reference and, unless the deposit is disputed,
+// credits d.Amount to the user's wallet. Claiming the reference first
+// prevents double deposits, even for concurrent requests. A deposit that
+// would break the player's deposit limits is refused with a *LimitError and
+// the reference stays unclaimed; so is any deposit from a self-excluded
+// player (*ExcludedError).
+func RecordDeposit(tx *gorm.DB, d *models.Deposit) error {
+	if d.Status == "" {
+		d.Status = models.DepositCredited
+	}
+	return WalletTx(tx, func(tx *gorm.DB) error {
+		if d.Status == models.DepositCredited {
+			if err := CheckNotExcluded(tx, d.UserID); err != nil {
+				return err
+			}
+			if err := CheckDepositLimits(tx, d.UserID, d.Amount); err != nil {
+				return err
+			}
+		}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(d)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrDuplicateDeposit
+		}
+		if d.Status != models.DepositCredited {
+			return nil
+		}
+		return creditDeposit(tx, d)
+	})
+}
+
+// ResolveDisputedDeposit credits a disputed deposit with the amount the
+// provider confirmed, once an operator has reviewed it. A review doesn't
+// override the player's own protections: while they are excluded, or when
+// the deposit would break one of their deposit limits, it stays disputed
+// and an *ExcludedError or *LimitError is returned.
+func ResolveDisputedDeposit(tx *gorm.DB, id uint) (*models.Deposit, error) {
+	var d models.Deposit
+	err := WalletTx(tx, func(tx *gorm.DB) error {
+		res := tx.Model(&models.Deposit{}).
+			Where("id = ? AND status = ?", id, models.DepositDisputed).
+			Update("status", models.DepositCredited)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrDepositNotOpen
+		}
+		if err := tx.First(&d, id).Error; err != nil {
+			return err
+		}
+		if err := CheckNotExcluded(tx, d.UserID); err != nil {
+			return err
+		}
+		if err := CheckDepositLimits(tx, d.UserID, d.Amount); err != nil {
+			return err
+		}
+		return creditDeposit(tx, &d)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func creditDeposit(tx *gorm.DB, d *models.Deposit) error {
+	source, err := SystemAccount(tx, ExternalDepositsCode, models.ExternalAccount)
+	if err != nil {
+		return err
+	}
+	wallet, err := WalletAccount(tx, d.UserID)
+	if err != nil {
+		return err
+	}
+	entry := &models.JournalEntry{Kind: models.DepositEntry, UserID: &d.UserID, Reference: d.Reference, Memo: d.Provider}
+	if err := Transfer(tx, entry, source, wallet, d.Amount); err != nil {
+		return err
+	}
+	if err := recordTransaction(tx, wallet, models.DepositTransaction, d.Amount, nil, d.Reference); err != nil {
+		return err
+	}
+	// The first deposit may be the last thing a referral was waiting for.
+	_, err = qualifyReferral(tx, d.UserID)
+	return err
+}
+
+// ConfirmDeposit checks d.Reference with the provider and fills the deposit
+// in from what the provider reports: the amount that arrived, the payer and
+// when it was paid. The deposit is credited unless that amount isn't
+// d.ExpectedAmount or the payer isn't the player, in which case it is marked
+// disputed with the reason. Provider errors, payments.ErrReferenceNotFound
+// among them, are returned as they are and leave the deposit untouched.
+func ConfirmDeposit(ctx context.Context, verifier payments.DepositVerifier, user *models.User, d *models.Deposit) error {
+	verified, err := verifier.Verify(ctx, d.Reference)
+	if err != nil {
+		return err
+	}
+
+	d.Amount = verified.Amount
+	d.Payer = verified.Payer
+	if !verified.PaidAt.IsZero() {
+		d.PaidAt = &verified.PaidAt
+	}
+	d.Status = models.DepositCredited
+	d.DisputeReason = ""
+	switch {
+	case verified.Amount != d.ExpectedAmount:
+		d.Status = models.DepositDisputed
+		d.DisputeReason = "amount does not match the payment"
+	case !PayerMatches(verifier.Name(), user.Phone, verified.Payer):
+		d.Status = models.DepositDisputed
+		d.DisputeReason = "payment was not sent from your phone number"
+	}
+	return nil
+}
+
+// PayerMatches reports whether the payer a provider reports can be the
+// player with this phone number. Mobile-money providers report the paying
+// number, compared by NormalizePhone so "+251 911 234 567" and "0911234567"
+// are the same payer; a player without a phone never matches. Bank
+// transfers report the account holder's name and account, which players
+// never give us, so there is nothing to compare and they always match.
+func PayerMatches(provider, phone, payer string) bool {
+	if provider == "bank" {
+		return true
+	}
+	own := NormalizePhone(phone)
+	return own != "" && strings.Contains(digitsOf(payer), own)
+}
+
+// DepositIntentTTL is how long a player has to pay after announcing a deposit.
+const DepositIntentTTL = 24 * time.Hour
+
+var ErrNoMatchingIntent = errors.New("no pending deposit intent for this amount")
+
+// CreateDepositIntent records that a user is about to pay amount.
+func CreateDepositIntent(tx *gorm.DB, userID uint, amount models.Money) (*models.DepositIntent, error) {
+	intent := &models.DepositIntent{
+		UserID:    userID,
+		Amount:    amount,
+		Status:    models.IntentPending,
+		ExpiresAt: time.Now().Add(DepositIntentTTL),
+	}
+	if err := tx.Create(intent).Error; err != nil {
+		return nil, err
+	}
+	return intent, nil
+}
+
+// MatchDepositIntent pairs a deposit with the user's oldest open intent for
+// d.ExpectedAmount and records it through RecordDeposit, the same path as a
+// provider-verified deposit.
+func MatchDepositIntent(tx *gorm.DB, d *models.Deposit) (*models.DepositIntent, error) {
+	var intent models.DepositIntent
+	err := WalletTx(tx, func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND amount = ? AND status = ? AND expires_at > ?",
+				d.UserID, d.ExpectedAmount, models.IntentPending, time.Now()).
+			Order("id").
+			First(&intent).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNoMatchingIntent
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := RecordDeposit(tx, d); err != nil {
+			return err
+		}
+		intent.Status = models.IntentMatched
+		intent.DepositID = &d.ID
+		return tx.Save(&intent).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/models"
+	"github.com/bellapacxx/bingo-backend/payments"
+)
+
+// stubVerifier confirms one payment, under the provider name it is given.
+type stubVerifier struct {
+	name    string
+	payment payments.VerifiedDeposit
+	err     error
+}
+
+func (v stubVerifier) Name() string { return v.name }
+
+func (v stubVerifier) Verify(_ context.Context, reference string) (*payments.VerifiedDeposit, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+	if reference != v.payment.Reference {
+		return nil, payments.ErrReferenceNotFound
+	}
+	p := v.payment
+	return &p, nil
+}
+
+func TestConfirmDeposit(t *testing.T) {
+	paidAt := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	player := &models.User{ID: 5, Phone: "0911234567"}
+	tests := []struct {
+		name     string
+		expected models.Money
+		payer    string
+		status   models.DepositStatus
+		reason   string
+	}{
+		{"matches", 15000, "+251911234567", models.DepositCredited, ""},
+		{"less arrived", 20000, "251911234567", models.DepositDisputed, "amount does not match the payment"},
+		{"someone else paid", 15000, "0922000000", models.DepositDisputed, "payment was not sent from your phone number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := stubVerifier{name: "mobile_money", payment: payments.VerifiedDeposit{
+				Provider: "mobile_money", Reference: "TX1", Amount: 15000, Payer: tt.payer, PaidAt: paidAt,
+			}}
+			// What a forged receipt claims is replaced by what the provider confirms.
+			d := &models.Deposit{UserID: player.ID, Reference: "TX1", ExpectedAmount: tt.expected, Amount: 99900, Payer: "forged"}
+			if err := ConfirmDeposit(context.Background(), v, player, d); err != nil {
+				t.Fatal(err)
+			}
+			if d.Amount != 15000 || d.Payer != tt.payer || d.PaidAt == nil || !d.PaidAt.Equal(paidAt) {
+				t.Errorf("deposit = %s from %q at %v, want the provider's 150.00 from %q at %v", d.Amount, d.Payer, d.PaidAt, tt.payer, paidAt)
+			}
+			if d.Status != tt.status || d.DisputeReason != tt.reason {
+				t.Errorf("status = %s (%q), want %s (%q)", d.Status, d.DisputeReason, tt.status, tt.reason)
+			}
+		})
+	}
+}
+
+func TestConfirmDepositProviderErrors(t *testing.T) {
+	player := &models.User{ID: 5, Phone: "0911234567"}
+	down := errors.New("provider returned 503")
+	for name, v := range map[string]stubVerifier{
+		"unknown reference": {name: "mobile_money", payment: payments.VerifiedDeposit{Reference: "OTHER"}},
+		"provider down":     {name: "mobile_money", err: down},
+	} {
+		d := &models.Deposit{Reference: "TX1", ExpectedAmount: 100, Status: models.DepositDisputed}
+		err := ConfirmDeposit(context.Background(), v, player, d)
+		if err == nil {
+			t.Errorf("%s: confirmed a payment the provider doesn't have", name)
+		}
+		if d.Status != models.DepositDisputed || d.Amount != 0 {
+			t.Errorf("%s: deposit changed on error: %+v", name, d)
+		}
+	}
+}
+
+func TestPayerMatchesPerProvider(t *testing.T) {
+	tests := []struct {
+		provider, phone, payer string
+		want                   bool
+	}{
+		{"mobile_money", "0911234567", "251911234567", true},
+		{"mobile_money", "+251 911 234 567", "0911234567", true},
+		{"mobile_money", "0911234567", "251922000000", false},
+		{"mobile_money", "", "251911234567", false},
+		{"fake", "0911234567", "+251911234567", true},
+		{"fake", "0911234567", "Abebe", false},
+		// Bank payers are an account holder and account, never a phone.
+		{"bank", "0911234567", "ABEBE KEBEDE 1000123456789", true},
+		{"bank", "", "ABEBE KEBEDE", true},
+	}
+	for _, tt := range tests {
+		if got := PayerMatches(tt.provider, tt.phone, tt.payer); got != tt.want {
+			t.Errorf("PayerMatches(%q, %q, %q) = %v, want %v", tt.provider, tt.phone, tt.payer, got, tt.want)
+		}
+	}
+}
+
+// A bank transfer the player sent is credited although its payer is a name.
+func TestConfirmBankDeposit(t *testing.T) {
+	v := stubVerifier{name: "bank", payment: payments.VerifiedDeposit{
+		Provider: "bank", Reference: "FT1", Amount: 50000, Payer: "ABEBE KEBEDE 1000123456789",
+	}}
+	d := &models.Deposit{Reference: "FT1", ExpectedAmount: 50000}
+	if err := ConfirmDeposit(context.Background(), v, &models.User{Phone: "0911234567"}, d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Status != models.DepositCredited {
+		t.Errorf("status = %s (%q), want credited", d.Status, d.DisputeReason)
+	}
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/models"
+	"gorm.io/gorm"
+)
+
+var ErrHoldNotActive = errors.New("stake hold is no longer active")
+
+// PlaceHold reserves one stake for a selected card. The wallet account is
+// locked while the stake moves to escrow, so the same balance can't back
+// cards in two lobbies at once, and the player's loss and rounds limits are
+// checked under the same lock. The stake is paid from cash and bonus money
+// in StakeFundingOrder.
+func PlaceHold(userID uint, stake, cardID int, amount models.Money) (*models.StakeHold, error) {
+	hold := &models.StakeHold{
+		UserID: userID,
+		Stake:  stake,
+		CardID: cardID,
+		Amount: amount,
+		Status: models.HoldActive,
+	}
+	err := WalletTx(config.DB, func(tx *gorm.DB) error {
+		if err := lockWallet(tx, userID); err != nil {
+			return err
+		}
+		if err := CheckNotExcluded(tx, userID); err != nil {
+			return err
+		}
+		wallet, err := WalletAccount(tx, userID)
+		if err != nil {
+			return err
+		}
+		bonus, err := BonusWalletAccount(tx, userID)
+		if err != nil {
+			return err
+		}
+		cash, fromBonus := splitStake(wallet, bonus, amount)
+		if err := CheckStakeLimits(tx, userID, stake, amount, fromBonus); err != nil {
+			return err
+		}
+		escrow, err := SystemAccount(tx, StakeEscrowCode, models.EscrowAccount)
+		if err != nil {
+			return err
+		}
+		hold.Bonus = fromBonus
+		if err := tx.Create(hold).Error; err != nil {
+			return err
+		}
+		entry := &models.JournalEntry{Kind: models.StakeHoldEntry, UserID: &userID, Reference: holdRef(hold.ID)}
+		return PostEntry(tx, entry, bucketLegs(wallet, bonus, escrow, cash, fromBonus, -1)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// ReleaseHold returns a held stake to the buckets it was paid from.
+func ReleaseHold(holdID uint) error {
+	return WalletTx(config.DB, func(tx *gorm.DB) error {
+		hold, err := claimHold(tx, holdID, models.HoldReleased, nil)
+		if err != nil {
+			return err
+		}
+		wallet, err := WalletAccount(tx, hold.UserID)
+		if err != nil {
+			return err
+		}
+		bonus, err := BonusWalletAccount(tx, hold.UserID)
+		if err != nil {
+			return err
+		}
+		escrow, err := SystemAccount(tx, StakeEscrowCode, models.EscrowAccount)
+		if err != nil {
+			return err
+		}
+		entry := &models.JournalEntry{Kind: models.StakeReleaseEntry, UserID: &hold.UserID, Reference: holdRef(hold.ID)}
+		return PostEntry(tx, entry, bucketLegs(wallet, bonus, escrow, hold.Amount-hold.Bonus, hold.Bonus, 1)...)
+	})
+}
+
+// CaptureHold turns a held stake into a debit by moving it into the game pot
+// and records the paid entry for the round. The whole stake counts towards
+// the player's bonus wagering; only the cash part shows in their history.
+func CaptureHold(holdID, gameID uint) error {
+	return WalletTx(config.DB, func(tx *gorm.DB) error {
+		hold, err := claimHold(tx, holdID, models.HoldCaptured, &gameID)
+		if err != nil {
+			return err
+		}
+		escrow, err := SystemAccount(tx, StakeEscrowCode, models.EscrowAccount)
+		if err != nil {
+			return err
+		}
+		pot, err := GamePotAccount(tx, gameID)
+		if err != nil {
+			return err
+		}
+		entry := &models.JournalEntry{Kind: models.StakeEntry, UserID: &hold.UserID, GameID: &gameID, Reference: holdRef(hold.ID)}
+		if err := Transfer(tx, entry, escrow, pot, hold.Amount); err != nil {
+			return err
+		}
+		// The wallet was debited when the hold was placed; the stake only
+		// shows in the player's history once it is actually spent
+		if cash := hold.Amount - hold.Bonus; cash > 0 {
+			wallet, err := WalletAccount(tx, hold.UserID)
+			if err != nil {
+				return err
+			}
+			if err := recordTransaction(tx, wallet, models.StakeTransaction, cash, &gameID, entry.Reference); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&models.RoundEntry{
+			GameID: gameID,
+			UserID: hold.UserID,
+			CardID: hold.CardID,
+			HoldID: hold.ID,
+			Stake:  hold.Amount,
+			Bonus:  hold.Bonus,
+			Status: models.EntryPaid,
+		}).Error; err != nil {
+			return err
+		}
+		return applyWagering(tx, hold.UserID, hold.Amount)
+	})
+}
+
+// ReleaseActiveHolds refunds every hold still active, e.g. after a restart
+// dropped the in-memory lobbies that owned them.
+func ReleaseActiveHolds() {
+	var ids []uint
+	if err := config.DB.Model(&models.StakeHold{}).Where("status = ?", models.HoldActive).Pluck("id", &ids).Error; err != nil {
+		log.Printf("[Escrow] failed to load active holds: %v", err)
+		return
+	}
+	for _, id := range ids {
+		if err := ReleaseHold(id); err != nil && !errors.Is(err, ErrHoldNotActive) {
+			log.Printf("[Escrow] failed to release hold %d: %v", id, err)
+		}
+	}
+	if len(ids) > 0 {
+		log.Printf("[Escrow] released %d stale holds", len(ids))
+	}
+}
+
+// claimHold moves an active hold to its final status. The conditional update
+// makes release and capture mutually exclusive.
+func claimHold(tx *gorm.DB, holdID uint, status models.HoldStatus, gameID *uint) (*models.StakeHold, error) {
+	res := tx.Model(&models.StakeHold{}).
+		Where("id = ? AND status = ?", holdID, models.HoldActive).
+		Updates(map[string]any{"status": status, "game_id": gameID})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrHoldNotActive
+	}
+
+	var hold models.StakeHold
+	if err := tx.First(&hold, holdID).Error; err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+func holdRef(id uint) string {
+	return fmt.Sprintf("hold:%d", id)
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/models"
+	"gorm.io/gorm"
+)
+
+// ExclusionPeriods are the self-exclusion lengths players can pick. A zero
+// duration means permanent.
+var ExclusionPeriods = map[string]time.Duration{
+	"24h":       24 * time.Hour,
+	"7d":        7 * 24 * time.Hour,
+	"30d":       30 * 24 * time.Hour,
+	"permanent": 0,
+}
+
+var ErrUnknownExclusionPeriod = errors.New("period must be 24h, 7d, 30d or permanent")
+
+// ExcludedError is returned when a self-excluded player tries to play or
+// deposit. Its message is meant for the player.
+type ExcludedError struct {
+	Exclusion models.SelfExclusion
+}
+
+func (e *ExcludedError) Error() string {
+	if e.Exclusion.EndsAt == nil {
+		return "You have excluded yourself from play permanently."
+	}
+	return fmt.Sprintf("You have excluded yourself from play until %s.", e.Exclusion.EndsAt.Format("2006-01-02 15:04 MST"))
+}
+
+// ActiveExclusion returns the exclusion keeping a player out right now, the
+// one ending last if there are several, or nil.
+func ActiveExclusion(tx *gorm.DB, userID uint) (*models.SelfExclusion, error) {
+	now := time.Now()
+	var exclusions []models.SelfExclusion
+	if err := tx.Where("user_id = ? AND starts_at <= ? AND (ends_at IS NULL OR ends_at > ?)", userID, now, now).
+		Order("ends_at DESC NULLS FIRST").Limit(1).Find(&exclusions).Error; err != nil {
+		return nil, err
+	}
+	if len(exclusions) == 0 {
+		return nil, nil
+	}
+	return &exclusions[0], nil
+}
+
+// CheckNotExcluded returns an *ExcludedError while the player is excluded.
+func CheckNotExcluded(tx *gorm.DB, userID uint) error {
+	exclusion, err := ActiveExclusion(tx, userID)
+	if err != nil || exclusion == nil {
+		return err
+	}
+	return &ExcludedError{Exclusion: *exclusion}
+}
+
+// ExcludeUser starts a self-exclusion now. A new exclusion never shortens
+// one already running, and the player is taken out of every lobby they are
+// connected to; stakes held for the next round are released.
+func ExcludeUser(userID uint, period string) (*models.SelfExclusion, error) {
+	length, ok := ExclusionPeriods[period]
+	if !ok {
+		return nil, ErrUnknownExclusionPeriod
+	}
+
+	now := time.Now()
+	exclusion := &models.SelfExclusion{UserID: userID, Period: period, StartsAt: now}
+	if length > 0 {
+		ends := now.Add(length)
+		exclusion.EndsAt = &ends
+	}
+
+	current, err := ActiveExclusion(config.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && (current.EndsAt == nil || (exclusion.EndsAt != nil && !exclusion.EndsAt.After(*current.EndsAt))) {
+		return current, nil
+	}
+	if err := config.DB.Create(exclusion).Error; err != nil {
+		return nil, err
+	}
+
+	DisconnectUser(userID, "🛑 "+(&ExcludedError{Exclusion: *exclusion}).Error())
+	return exclusion, nil
+}
+
+// ConnectedUsers returns every player with an open lobby connection.
+func ConnectedUsers() []uint {
+	LobbiesMu.Lock()
+	defer LobbiesMu.Unlock()
+	seen := make(map[uint]bool)
+	var users []uint
+	for _, l := range Lobbies {
+		l.mu.RLock()
+		for userID := range l.clients {
+			if !seen[userID] {
+				seen[userID] = true
+				users = append(users, userID)
+			}
+		}
+		l.mu.RUnlock()
+	}
+	return users
+}
+
+// NotifyPromotion sends a promotional message to a connected player, unless
+// they are self-excluded. Every marketing message must go through here.
+func NotifyPromotion(userID uint, message string) bool {
+	excluded, err := ActiveExclusion(config.DB, userID)
+	if err != nil || excluded != nil {
+		return false
+	}
+	NotifyUser(userID, message)
+	return true
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+
+	"github.com/bellapacxx/bingo-backend/game"
+	"github.com/bellapacxx/bingo-backend/models"
+	"gorm.io/gorm"
+)
+
+// ErrSeedNotRevealed is returned while a game's server seed is still secret.
+var ErrSeedNotRevealed = errors.New("server seed is revealed once the game is over")
+
+// ErrNoSeeds is returned for games drawn before provably fair draws.
+var ErrNoSeeds = errors.New("game was not drawn from committed seeds")
+
+// DrawVerification recomputes a game's draw from its revealed seeds.
+type DrawVerification struct {
+	GameID         uint   `json:"gameId"`
+	Algorithm      string `json:"algorithm"`
+	ServerSeed     string `json:"serverSeed"`
+	ServerSeedHash string `json:"serverSeedHash"` // the commitment published before the round
+	ClientSeed     string `json:"clientSeed"`
+	CommitmentOK   bool   `json:"commitmentOk"` // the seed hashes to the commitment
+	DrawOrder      []int  `json:"drawOrder"`    // all balls in the order the seeds give
+	Drawn          []int  `json:"drawn"`        // balls the game actually drew
+	DrawOK         bool   `json:"drawOk"`       // drawn is the start of drawOrder
+	Verified       bool   `json:"verified"`
+}
+
+// VerifyDraw reveals a finished or voided game's server seed and checks
+// the balls it drew against the order its seeds give.
+func VerifyDraw(db *gorm.DB, gameID uint) (*DrawVerification, error) {
+	var g models.Game
+	if err := db.First(&g, gameID).Error; err != nil {
+		return nil, err
+	}
+	if g.Status != "finished" && g.Status != "voided" {
+		return nil, ErrSeedNotRevealed
+	}
+	if g.ServerSeed == "" {
+		return nil, ErrNoSeeds
+	}
+
+	var numbers []string
+	if len(g.NumbersJSON) > 0 {
+		if err := json.Unmarshal(g.NumbersJSON, &numbers); err != nil {
+			return nil, err
+		}
+	}
+	drawn := make([]int, len(numbers))
+	for i, n := range numbers {
+		v, err := strconv.Atoi(n)
+		if err != nil {
+			return nil, err
+		}
+		drawn[i] = v
+	}
+
+	order := game.DrawOrder(g.ServerSeed, g.ClientSeed, engineConfig().Balls)
+	v := &DrawVerification{
+		GameID:         g.ID,
+		Algorithm:      game.FairAlgorithm,
+		ServerSeed:     g.ServerSeed,
+		ServerSeedHash: g.ServerSeedHash,
+		ClientSeed:     g.ClientSeed,
+		CommitmentOK:   game.Commit(g.ServerSeed) == g.ServerSeedHash,
+		DrawOrder:      order,
+		Drawn:          drawn,
+		DrawOK:         len(drawn) <= len(order) && slices.Equal(drawn, order[:len(drawn)]),
+	}
+	v.Verified = v.CommitmentOK && v.DrawOK
+	return v, nil
+}
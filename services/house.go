@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LobbySettingsFor returns the configured rules for a stake lobby, or the
+// defaults when none are stored.
+func LobbySettingsFor(stake int) (models.LobbySettings, error) {
+	var s models.LobbySettings
+	err := config.DB.Where("stake = ?", stake).First(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.DefaultLobbySettings(stake), nil
+	}
+	return s, err
+}
+
+// SaveLobbySettings stores the rules for a lobby. They apply from the next
+// round; a round in progress keeps the rules it started with.
+func SaveLobbySettings(s *models.LobbySettings) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if _, err := ResolvePatterns(s.Patterns); err != nil {
+		return errors.Join(models.ErrInvalidLobbySettings, err)
+	}
+	return config.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "stake"}},
+		DoUpdates: clause.AssignmentColumns([]string{"payout_bps", "rake_bps", "min_pot", "max_payout", "claim_window_ms", "split_rounding", "jackpot_bps", "jackpot_max_balls", "patterns", "max_cards", "updated_by", "updated_at"}),
+	}).Create(s).Error
+}
+
+// bookRake moves the house share of a game's stakes to house revenue.
+func bookRake(gameID uint, amount models.Money) error {
+	if amount <= 0 {
+		return nil
+	}
+	return WalletTx(config.DB, func(tx *gorm.DB) error {
+		pot, err := GamePotAccount(tx, gameID)
+		if err != nil {
+			return err
+		}
+		house, err := SystemAccount(tx, HouseRakeCode, models.HouseRakeAccount)
+		if err != nil {
+			return err
+		}
+		entry := &models.JournalEntry{Kind: models.RakeEntry, GameID: &gameID}
+		return Transfer(tx, entry, pot, house, amount)
+	})
+}
+
+// reverseRake hands the rake taken from a game back to its pot, so a voided
+// round can refund stakes in full.
+func reverseRake(tx *gorm.DB, gameID uint, pot *models.LedgerAccount) error {
+	house, err := SystemAccount(tx, HouseRakeCode, models.HouseRakeAccount)
+	if err != nil {
+		return err
+	}
+	var taken models.Money
+	if err := tx.Model(&models.LedgerPosting{}).
+		Joins("JOIN journal_entries ON journal_entries.id = ledger_postings.entry_id").
+		Where("journal_entries.game_id = ? AND ledger_postings.account_id = ?", gameID, house.ID).
+		Select("COALESCE(SUM(ledger_postings.amount), 0)").
+		Scan(&taken).Error; err != nil {
+		return err
+	}
+	if taken <= 0 {
+		return nil
+	}
+	entry := &models.JournalEntry{Kind: models.RakeReversalEntry, GameID: &gameID}
+	return Transfer(tx, entry, house, pot, taken)
+}
+
+// subsidizePot tops a pot up from house funds when the guaranteed prize is
+// more than the stakes left in it.
+func subsidizePot(tx *gorm.DB, gameID uint, pot *models.LedgerAccount, prize models.Money) error {
+	if pot.Balance >= prize {
+		return nil
+	}
+	subsidy, err := SystemAccount(tx, HouseSubsidyCode, models.EquityAccount)
+	if err != nil {
+		return err
+	}
+	entry := &models.JournalEntry{Kind: models.SubsidyEntry, GameID: &gameID}
+	return Transfer(tx, entry, subsidy, pot, prize-pot.Balance)
+}
+
+// GameRevenue is what the house earned on one round.
+type GameRevenue struct {
+	GameID      uint         `json:"gameId"`
+	Stake       int          `json:"stake"`
+	RoundNumber int          `json:"roundNumber"`
+	Status      string       `json:"status"`
+	StartTime   time.Time    `json:"startTime"`
+	Players     int          `json:"players"`
+	Stakes      models.Money `json:"stakes"`
+	Payout      models.Money `json:"payout"` // prizes, cash and bonus
+	Rake        models.Money `json:"rake"`
+	Subsidy     models.Money `json:"subsidy"`
+	JackpotFeed models.Money `json:"jackpotFeed"` // stakes added to the jackpot
+	JackpotPaid models.Money `json:"jackpotPaid"` // jackpot won in this round
+	Net         models.Money `json:"net"`         // rake minus subsidy
+}
+
+// RevenueFilter narrows a revenue query. Zero values mean no limit.
+type RevenueFilter struct {
+	Stake    int
+	From, To *time.Time
+	Limit    int
+}
+
+// RoundRevenue reports house revenue per game, newest first, straight from
+// the ledger.
+func RoundRevenue(db *gorm.DB, f RevenueFilter) ([]GameRevenue, error) {
+	house, err := SystemAccount(db, HouseRakeCode, models.HouseRakeAccount)
+	if err != nil {
+		return nil, err
+	}
+	subsidy, err := SystemAccount(db, HouseSubsidyCode, models.EquityAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerSum := `(SELECT COALESCE(SUM(p.amount), 0) FROM ledger_postings p
+		JOIN journal_entries e ON e.id = p.entry_id
+		WHERE e.game_id = games.id AND p.account_id = ?)`
+	jackpotSum := `(SELECT COALESCE(SUM(p.amount), 0) FROM ledger_postings p
+		JOIN journal_entries e ON e.id = p.entry_id
+		JOIN ledger_accounts a ON a.id = p.account_id
+		WHERE e.game_id = games.id AND a.type = 'jackpot' AND e.kind IN ?)`
+	q := db.Model(&models.Game{}).
+		Select(`games.id AS game_id, games.stake, games.round_number, games.status, games.start_time,
+			(SELECT COUNT(*) FROM round_entries r WHERE r.game_id = games.id) AS players,
+			(SELECT COALESCE(SUM(r.stake), 0) FROM round_entries r WHERE r.game_id = games.id AND r.status <> ?) AS stakes,
+			(SELECT COALESCE(SUM(t.amount), 0) FROM transactions t WHERE t.game_id = games.id AND t.type IN ?) AS payout,
+			`+ledgerSum+` AS rake,
+			-`+ledgerSum+` AS subsidy,
+			`+jackpotSum+` AS jackpot_feed,
+			-`+jackpotSum+` AS jackpot_paid`,
+			models.EntryRefunded, []models.TransactionType{models.WinTransaction, models.BonusWinTransaction}, house.ID, subsidy.ID,
+			[]models.EntryKind{models.JackpotFeedEntry, models.JackpotReturnEntry},
+			[]models.EntryKind{models.JackpotPayoutEntry}).
+		Order("games.id DESC")
+	if f.Stake != 0 {
+		q = q.Where("games.stake = ?", f.Stake)
+	}
+	if f.From != nil {
+		q = q.Where("games.start_time >= ?", *f.From)
+	}
+	if f.To != nil {
+		q = q.Where("games.start_time < ?", *f.To)
+	}
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+
+	var rows []GameRevenue
+	if err := q.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].Net = rows[i].Rake - rows[i].Subsidy
+	}
+	return rows, nil
+}
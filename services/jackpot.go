@@ -0,0 +1,115 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/models"
+	"gorm.io/gorm"
+)
+
+func jackpotCode(stake int) string { return fmt.Sprintf("jackpot:stake:%d", stake) }
+
+// JackpotAccount returns the progressive jackpot of a stake lobby. It lives
+// in the ledger, so the balance carries over across rounds and restarts.
+func JackpotAccount(tx *gorm.DB, stake int) (*models.LedgerAccount, error) {
+	return SystemAccount(tx, jackpotCode(stake), models.JackpotAccount)
+}
+
+// JackpotBalance returns what a lobby's jackpot currently holds.
+func JackpotBalance(stake int) (models.Money, error) {
+	var acct models.LedgerAccount
+	err := config.DB.Where("code = ?", jackpotCode(stake)).Limit(1).Find(&acct).Error
+	return acct.Balance, err
+}
+
+// feedJackpot moves a round's jackpot slice out of its pot.
+func feedJackpot(gameID uint, stake int, amount models.Money) error {
+	if amount <= 0 {
+		return nil
+	}
+	return WalletTx(config.DB, func(tx *gorm.DB) error {
+		pot, err := GamePotAccount(tx, gameID)
+		if err != nil {
+			return err
+		}
+		jackpot, err := JackpotAccount(tx, stake)
+		if err != nil {
+			return err
+		}
+		entry := &models.JournalEntry{Kind: models.JackpotFeedEntry, GameID: &gameID}
+		return Transfer(tx, entry, pot, jackpot, amount)
+	})
+}
+
+// returnJackpotFeed hands a voided game's jackpot slice back to its pot. If
+// the jackpot was won since (a game voided at startup), the house covers
+// what the jackpot no longer holds.
+func returnJackpotFeed(tx *gorm.DB, gameID uint, stake int, pot *models.LedgerAccount) error {
+	jackpot, err := JackpotAccount(tx, stake)
+	if err != nil {
+		return err
+	}
+	var fed models.Money
+	if err := tx.Model(&models.LedgerPosting{}).
+		Joins("JOIN journal_entries ON journal_entries.id = ledger_postings.entry_id").
+		Where("journal_entries.game_id = ? AND ledger_postings.account_id = ?", gameID, jackpot.ID).
+		Select("COALESCE(SUM(ledger_postings.amount), 0)").
+		Scan(&fed).Error; err != nil {
+		return err
+	}
+	if fed <= 0 {
+		return nil
+	}
+
+	back := min(fed, jackpot.Balance)
+	if back > 0 {
+		entry := &models.JournalEntry{Kind: models.JackpotReturnEntry, GameID: &gameID}
+		if err := Transfer(tx, entry, jackpot, pot, back); err != nil {
+			return err
+		}
+	}
+	if back < fed {
+		return subsidizePot(tx, gameID, pot, pot.Balance+fed-back)
+	}
+	return nil
+}
+
+// payJackpot splits the whole jackpot between the winners who qualified for
+// it, setting their Jackpot share.
+func payJackpot(tx *gorm.DB, gameID uint, settings models.LobbySettings, winners []RoundWinner) error {
+	var qualified []int
+	for i, w := range winners {
+		if w.fullCard {
+			qualified = append(qualified, i)
+		}
+	}
+	if len(qualified) == 0 {
+		return nil
+	}
+
+	jackpot, err := JackpotAccount(tx, settings.Stake)
+	if err != nil {
+		return err
+	}
+	shares := settings.SplitPrize(jackpot.Balance, len(qualified))
+	for n, i := range qualified {
+		if shares[n] <= 0 {
+			continue
+		}
+		userID := winners[i].UserID
+		wallet, err := WalletAccount(tx, userID)
+		if err != nil {
+			return err
+		}
+		entry := &models.JournalEntry{Kind: models.JackpotPayoutEntry, UserID: &userID, GameID: &gameID}
+		if err := Transfer(tx, entry, jackpot, wallet, shares[n]); err != nil {
+			return err
+		}
+		if err := recordTransaction(tx, wallet, models.JackpotTransaction, shares[n], &gameID, ""); err != nil {
+			return err
+		}
+		winners[i].Jackpot = shares[n]
+	}
+	return nil
+}
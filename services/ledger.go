@@ -0,0 +1,189 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bellapacxx/bingo-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// System account codes
+const (
+	HouseRakeCode          = "house:rake"
+	ExternalDepositsCode   = "external:deposits"
+	ExternalPayoutsCode    = "external:payouts"
+	PendingWithdrawalsCode = "withdrawals:pending"
+	OpeningEquityCode      = "equity:opening"
+	StakeEscrowCode        = "escrow:stakes"
+	HouseSubsidyCode       = "equity:subsidy"    // funds guaranteed prizes
+	PromotionsCode         = "equity:promotions" // funds bonus money
+	ReferralsCode          = "equity:referrals"  // funds referral rewards
+)
+
+var (
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrUnbalancedEntry   = errors.New("journal entry does not balance")
+)
+
+// Leg is one side of a journal entry before it is written.
+type Leg struct {
+	Account *models.LedgerAccount
+	Amount  models.Money
+}
+
+// canGoNegative reports whether an account may be overdrawn. Only the
+// accounts that model the outside world are allowed to.
+func canGoNegative(t models.AccountType) bool {
+	return t == models.ExternalAccount || t == models.EquityAccount
+}
+
+func walletCode(userID uint) string { return fmt.Sprintf("wallet:%d", userID) }
+func bonusCode(userID uint) string  { return fmt.Sprintf("bonus:%d", userID) }
+func potCode(gameID uint) string    { return fmt.Sprintf("pot:game:%d", gameID) }
+
+// findOrCreateAccount returns the account with the given code, creating it
+// when missing. The bool reports whether this call created it.
+func findOrCreateAccount(tx *gorm.DB, acct models.LedgerAccount) (*models.LedgerAccount, bool, error) {
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&acct)
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	created := res.RowsAffected == 1
+
+	var out models.LedgerAccount
+	if err := tx.Where("code = ?", acct.Code).First(&out).Error; err != nil {
+		return nil, false, err
+	}
+	return &out, created, nil
+}
+
+// SystemAccount returns a house-side account such as HouseRakeCode.
+func SystemAccount(tx *gorm.DB, code string, typ models.AccountType) (*models.LedgerAccount, error) {
+	acct, _, err := findOrCreateAccount(tx, models.LedgerAccount{Code: code, Type: typ})
+	return acct, err
+}
+
+// GamePotAccount returns the pot that collects stakes for a single game.
+func GamePotAccount(tx *gorm.DB, gameID uint) (*models.LedgerAccount, error) {
+	acct, _, err := findOrCreateAccount(tx, models.LedgerAccount{
+		Code:   potCode(gameID),
+		Type:   models.RoundPotAccount,
+		GameID: &gameID,
+	})
+	return acct, err
+}
+
+// WalletAccount returns the ledger wallet for a user, opening an empty one
+// the first time. Balances from before the ledger are booked once, at
+// startup, by the opening balance migration in config; a wallet opened here
+// never takes money from users.balance.
+func WalletAccount(tx *gorm.DB, userID uint) (*models.LedgerAccount, error) {
+	acct, _, err := findOrCreateAccount(tx, models.LedgerAccount{
+		Code:   walletCode(userID),
+		Type:   models.WalletAccount,
+		UserID: &userID,
+	})
+	return acct, err
+}
+
+// PostEntry writes a balanced journal entry and applies it to the account
+// balances. Wallet legs also update users.balance inside the same
+// transaction, so tx must be a transaction.
+func PostEntry(tx *gorm.DB, entry *models.JournalEntry, legs ...Leg) error {
+	return writeEntry(tx, entry, legs, true)
+}
+
+// Transfer posts a two-leg entry moving amount from one account to another.
+func Transfer(tx *gorm.DB, entry *models.JournalEntry, from, to *models.LedgerAccount, amount models.Money) error {
+	if amount <= 0 {
+		return fmt.Errorf("transfer amount must be positive, got %s", amount)
+	}
+	return PostEntry(tx, entry, Leg{Account: from, Amount: -amount}, Leg{Account: to, Amount: amount})
+}
+
+func writeEntry(tx *gorm.DB, entry *models.JournalEntry, legs []Leg, syncWallets bool) error {
+	var sum models.Money
+	for _, leg := range legs {
+		sum += leg.Amount
+	}
+	if len(legs) < 2 || sum != 0 {
+		return ErrUnbalancedEntry
+	}
+
+	// Lock the account rows and re-read their balances before moving money.
+	// Locking in ID order keeps two opposite transfers from deadlocking.
+	ids := make([]uint, 0, len(legs))
+	for _, leg := range legs {
+		ids = append(ids, leg.Account.ID)
+	}
+	var locked []models.LedgerAccount
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).Order("id").Find(&locked).Error; err != nil {
+		return err
+	}
+	accounts := make(map[uint]models.LedgerAccount, len(locked))
+	for _, a := range locked {
+		accounts[a.ID] = a
+	}
+
+	if err := tx.Create(entry).Error; err != nil {
+		return err
+	}
+
+	for _, leg := range legs {
+		acct, ok := accounts[leg.Account.ID]
+		if !ok {
+			return fmt.Errorf("ledger account %d not found", leg.Account.ID)
+		}
+		if acct.Balance+leg.Amount < 0 && !canGoNegative(acct.Type) {
+			return ErrInsufficientFunds
+		}
+
+		if err := tx.Create(&models.LedgerPosting{
+			EntryID:   entry.ID,
+			AccountID: acct.ID,
+			Amount:    leg.Amount,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&acct).Update("balance", gorm.Expr("balance + ?", leg.Amount)).Error; err != nil {
+			return err
+		}
+
+		if syncWallets && acct.UserID != nil {
+			column := ""
+			switch acct.Type {
+			case models.WalletAccount:
+				column = "balance"
+			case models.BonusWalletAccount:
+				column = "bonus_balance"
+			}
+			if column != "" {
+				if err := tx.Model(&models.User{}).Where("id = ?", *acct.UserID).
+					Update(column, gorm.Expr(column+" + ?", leg.Amount)).Error; err != nil {
+					return err
+				}
+			}
+		}
+		acct.Balance += leg.Amount
+		accounts[acct.ID] = acct
+		leg.Account.Balance = acct.Balance
+	}
+	return nil
+}
+
+// VerifyAccount recomputes an account balance from its postings and returns
+// it together with the cached balance so callers can spot drift.
+func VerifyAccount(db *gorm.DB, accountID uint) (cached, derived models.Money, err error) {
+	var acct models.LedgerAccount
+	if err = db.First(&acct, accountID).Error; err != nil {
+		return 0, 0, err
+	}
+	err = db.Model(&models.LedgerPosting{}).
+		Where("account_id = ?", accountID).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&derived).Error
+	return acct.Balance, derived, err
+}
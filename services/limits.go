@@ -0,0 +1,218 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LimitCoolingOff is how long a raised or removed limit waits before it
+// takes effect.
+const LimitCoolingOff = 24 * time.Hour
+
+// LimitError says which limit blocked an action. Its message is meant for
+// the player.
+type LimitError struct {
+	Limit models.GamingLimit
+	Used  int64 // santim or rounds already used this period
+}
+
+func (e *LimitError) Error() string {
+	if e.Limit.Kind == models.RoundsLimit {
+		return fmt.Sprintf("You reached your %s limit of %d rounds. You can play again next period.",
+			e.Limit.Period, e.Limit.Value)
+	}
+	what := "deposit"
+	if e.Limit.Kind == models.LossLimit {
+		what = "loss"
+	}
+	left := max(models.Money(e.Limit.Value-e.Used), 0)
+	return fmt.Sprintf("This would go over your %s %s limit of %s (%s left this period).",
+		e.Limit.Period, what, models.Money(e.Limit.Value), left)
+}
+
+// UserLimits returns a player's limits, applying pending changes whose
+// cooling-off period has passed.
+func UserLimits(tx *gorm.DB, userID uint) ([]models.GamingLimit, error) {
+	var limits []models.GamingLimit
+	if err := tx.Where("user_id = ?", userID).Order("kind, period").Find(&limits).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	active := limits[:0]
+	for _, l := range limits {
+		if l.Pending != nil && l.PendingFrom != nil && !now.Before(*l.PendingFrom) {
+			if *l.Pending == 0 {
+				if err := tx.Delete(&l).Error; err != nil {
+					return nil, err
+				}
+				continue
+			}
+			l.Value, l.Pending, l.PendingFrom = *l.Pending, nil, nil
+			if err := tx.Save(&l).Error; err != nil {
+				return nil, err
+			}
+		}
+		active = append(active, l)
+	}
+	return active, nil
+}
+
+// SetLimit changes one of a player's limits. value 0 removes it. A stricter
+// limit applies immediately and cancels any pending raise; a looser one
+// applies after LimitCoolingOff.
+func SetLimit(userID uint, kind models.LimitKind, period models.LimitPeriod, value int64) (*models.GamingLimit, error) {
+	if !kind.Valid() || !period.Valid() || value < 0 {
+		return nil, errors.New("invalid limit")
+	}
+
+	var limit *models.GamingLimit
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		current, err := UserLimits(tx, userID)
+		if err != nil {
+			return err
+		}
+		for i := range current {
+			if current[i].Kind == kind && current[i].Period == period {
+				limit = &current[i]
+			}
+		}
+
+		switch {
+		case limit == nil && value == 0:
+			return nil
+		case limit == nil:
+			limit = &models.GamingLimit{UserID: userID, Kind: kind, Period: period, Value: value}
+			return tx.Create(limit).Error
+		case value != 0 && value <= limit.Value:
+			limit.Value, limit.Pending, limit.PendingFrom = value, nil, nil
+		default:
+			from := time.Now().Add(LimitCoolingOff)
+			limit.Pending, limit.PendingFrom = &value, &from
+		}
+		return tx.Save(limit).Error
+	})
+	return limit, err
+}
+
+// CheckDepositLimits returns a *LimitError if crediting amount would break
+// one of the player's deposit limits. The wallet row is locked so two
+// deposits can't both squeeze under the same limit.
+func CheckDepositLimits(tx *gorm.DB, userID uint, amount models.Money) error {
+	limits, err := limitsOfKind(tx, userID, models.DepositLimit)
+	if err != nil || len(limits) == 0 {
+		return err
+	}
+	if err := lockWallet(tx, userID); err != nil {
+		return err
+	}
+	for _, l := range limits {
+		var used models.Money
+		if err := tx.Model(&models.Transaction{}).
+			Where("user_id = ? AND type = ? AND created_at >= ?", userID, models.DepositTransaction, l.Period.Start(time.Now())).
+			Select("COALESCE(SUM(amount), 0)").Scan(&used).Error; err != nil {
+			return err
+		}
+		if used+amount > models.Money(l.Value) {
+			return &LimitError{Limit: l, Used: int64(used)}
+		}
+	}
+	return nil
+}
+
+// CheckStakeLimits returns a *LimitError if one more card at stake in the
+// lobby would break the player's loss or rounds limits. Stakes still held in
+// escrow count as played; more cards in a lobby the player already holds one
+// in are the same round. Losses are cash only: the part of a stake paid from
+// bonus money doesn't count, held or captured. bonus is the part of this
+// stake that would come from bonus money.
+func CheckStakeLimits(tx *gorm.DB, userID uint, lobby int, stake, bonus models.Money) error {
+	var limits []models.GamingLimit
+	for _, kind := range []models.LimitKind{models.LossLimit, models.RoundsLimit} {
+		l, err := limitsOfKind(tx, userID, kind)
+		if err != nil {
+			return err
+		}
+		limits = append(limits, l...)
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+
+	var held struct {
+		Rounds int64 // lobbies with held cards
+		Here   int64 // held cards in this lobby
+		Cash   models.Money
+	}
+	if err := tx.Model(&models.StakeHold{}).
+		Where("user_id = ? AND status = ?", userID, models.HoldActive).
+		Select("COUNT(DISTINCT stake) AS rounds, COUNT(*) FILTER (WHERE stake = ?) AS here, COALESCE(SUM(amount - bonus), 0) AS cash", lobby).
+		Scan(&held).Error; err != nil {
+		return err
+	}
+	joining := int64(1)
+	if held.Here > 0 {
+		joining = 0
+	}
+
+	for _, l := range limits {
+		since := l.Period.Start(time.Now())
+		switch l.Kind {
+		case models.RoundsLimit:
+			var played int64
+			if err := tx.Model(&models.RoundEntry{}).
+				Where("user_id = ? AND status <> ? AND created_at >= ?", userID, models.EntryRefunded, since).
+				Distinct("game_id").Count(&played).Error; err != nil {
+				return err
+			}
+			if used := played + held.Rounds; used+joining > l.Value {
+				return &LimitError{Limit: l, Used: used}
+			}
+		case models.LossLimit:
+			var net models.Money // cash staked minus cash won or refunded
+			if err := tx.Model(&models.Transaction{}).
+				Where("user_id = ? AND type IN ? AND created_at >= ?", userID, lossTransactions, since).
+				Select("COALESCE(SUM(CASE WHEN type = ? THEN amount ELSE -amount END), 0)", models.StakeTransaction).
+				Scan(&net).Error; err != nil {
+				return err
+			}
+			if used := max(net, 0) + held.Cash; used+stake-bonus > models.Money(l.Value) {
+				return &LimitError{Limit: l, Used: int64(used)}
+			}
+		}
+	}
+	return nil
+}
+
+// lossTransactions are the cash movements of play that make up a loss.
+var lossTransactions = []models.TransactionType{
+	models.StakeTransaction, models.WinTransaction, models.JackpotTransaction, models.RefundTransaction,
+}
+
+func limitsOfKind(tx *gorm.DB, userID uint, kind models.LimitKind) ([]models.GamingLimit, error) {
+	all, err := UserLimits(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var out []models.GamingLimit
+	for _, l := range all {
+		if l.Kind == kind {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+func lockWallet(tx *gorm.DB, userID uint) error {
+	wallet, err := WalletAccount(tx, userID)
+	if err != nil {
+		return err
+	}
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.LedgerAccount{}, wallet.ID).Error
+}
@@ -2,14 +2,15 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"math/rand"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/game"
 	"github.com/bellapacxx/bingo-backend/models"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
@@ -17,29 +18,32 @@ import (
 
 const (
 	DefaultCountdownSec = 30
-	DrawIntervalMS      = 200 // 1 number per 200ms
+	DrawInterval        = 6 * time.Second // between balls
+	ResultsDelay        = 7 * time.Second // a settled round stays on screen this long
+
+	// lobbyTick is how often the round clock is advanced.
+	lobbyTick = 100 * time.Millisecond
 )
 
+// Lobby connects one stake's round engine to players, the ledger and the
+// database. The engine decides what happens in a round; the lobby acts on
+// the events it returns.
 type Lobby struct {
-	Stake        int
-	clients      map[uint]*Client
-	Cards        map[uint][]int
-	CardIDs      map[uint]int
-	selectedIDs  map[int]bool
-	Status       string
-	Countdown    int
-	NumbersDrawn []string
-	roundDone    chan struct{}
-    drawCancel chan struct{} // <- new field
+	Stake   int
+	clients map[uint]*Client
+	holds   map[int]uint // cardID -> escrow hold backing it
 
 	mu          sync.RWMutex
+	engine      *game.Engine
 	currentGame *models.Game
-	// New: store current round winner
-	BingoWinner       *uint
-	 BingoWinnerName   *string
-	BingoWinnerCardID *int // cardID ✅
-	CheckedUsers      map[uint]bool
-	roundPot float64 // store total pot for the current round
+	paid        []RoundWinner        // winners with names and prizes, once paid
+	unpaid      []RoundWinner        // winners whose payout failed, retried when the round ends
+	roundPot    models.Money         // store potential winnings for the current round
+	jackpot     models.Money         // cached jackpot balance for broadcasts
+	settings    models.LobbySettings // payout rules the current round started with
+	// nextPatterns replaces the lobby's patterns for the next round only
+	nextPatterns []game.Pattern
+	fair         *game.FairDraw // seeds of the round being counted down or played
 }
 
 var (
@@ -48,20 +52,32 @@ var (
 	Stakes    = []int{10, 20, 50, 100}
 )
 
+// engineConfig is the round timing every lobby uses.
+func engineConfig() game.Config {
+	return game.Config{
+		Countdown:    DefaultCountdownSec * time.Second,
+		DrawInterval: DrawInterval,
+		ResultsDelay: ResultsDelay,
+		MinCards:     1,
+		Balls:        75,
+	}
+}
+
 func InitLobbyService() {
 	LoadCards()
+	LoadPatterns()
+	// Lobbies start empty, so no hold from a previous run can still be in use
+	ReleaseActiveHolds()
+	// Rounds cut off by the last shutdown are settled or refunded
+	RecoverInterruptedRounds()
 	for _, stake := range Stakes {
 		l := &Lobby{
-			Stake:       stake,
-			clients:     make(map[uint]*Client),
-			Cards:       make(map[uint][]int),
-			CardIDs:     make(map[uint]int),
-			selectedIDs: make(map[int]bool),
-			Status:      "waiting",
-			Countdown:   DefaultCountdownSec,
-			roundDone:   make(chan struct{}, 1),
-			drawCancel:  make(chan struct{}), // ← initialize here
+			Stake:   stake,
+			clients: make(map[uint]*Client),
+			holds:   make(map[int]uint),
+			engine:  game.New(engineConfig(), game.SystemClock{}, game.NewRNG()),
 		}
+		l.refreshJackpot()
 		Lobbies[stake] = l
 		go l.RunAutoRounds()
 	}
@@ -91,13 +107,12 @@ func (l *Lobby) removeClient(userID uint) {
 		delete(l.clients, userID)
 		client.Close() // safe closure
 	}
-	if cardID, ok := l.CardIDs[userID]; ok {
-		delete(l.selectedIDs, cardID)
-		delete(l.CardIDs, userID)
-	}
-	delete(l.Cards, userID)
+	// Leaving before the round starts gives the stakes back; paid cards stay
+	// in play if the player drops mid-round.
+	events, _ := l.engine.DeselectAll(userID)
 	l.mu.Unlock()
 
+	l.handle(events)
 	l.broadcastState()
 }
 
@@ -107,276 +122,750 @@ func (l *Lobby) clientCount() int {
 	return len(l.clients)
 }
 
-// -------------------- Card selection --------------------
-func (l *Lobby) canSelectCard() bool {
-	return l.Status == "waiting" || l.Status == "countdown"
+// stakeAmount is the price of one card in this lobby.
+func (l *Lobby) stakeAmount() models.Money {
+	return models.Birr(int64(l.Stake))
 }
 
+// -------------------- Card selection --------------------
+
+// SelectCard gives the user another card, holding one more stake, up to the
+// lobby's MaxCards. In a one-card lobby a player who already holds a card
+// swaps it instead and keeps their hold.
 func (l *Lobby) SelectCard(userID uint, cardID int) bool {
-	// Step 1: Read the global Cards slice safely
-	// 1️⃣ Fetch user from DB
-	var user models.User
-	if err := config.DB.First(&user, userID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			log.Printf("[Lobby %d] User %d not found", l.Stake, userID)
-			return false
-		}
-		log.Printf("[Lobby %d] DB error fetching user %d: %v", l.Stake, userID, err)
+	card, ok := cardByID(cardID)
+	if !ok {
+		log.Printf("[Lobby %d] invalid cardID %d", l.Stake, cardID)
 		return false
 	}
 
-	// 2️⃣ Check balance
-	if user.Balance < float64(l.Stake) {
-		l.notifyUser(userID, "Insufficient balance to select this card.")
-		log.Printf("[Lobby %d] User %d cannot select card %d: insufficient balance %.2f < %d", l.Stake, userID, cardID, user.Balance, l.Stake)
+	l.mu.RLock()
+	canSelect, taken := l.engine.CanSelect(), l.engine.Taken(cardID)
+	held, maxCards := l.engine.Selections(userID), l.engine.MaxCards()
+	l.mu.RUnlock()
+	if !canSelect {
+		log.Printf("[Lobby %d] User %d tried to select card %d but round in progress", l.Stake, userID, cardID)
+		return false
+	}
+	if taken {
+		log.Printf("[Lobby %d] Card %d already taken", l.Stake, cardID)
+		return false
+	}
+	if len(held) >= maxCards {
+		if maxCards == 1 {
+			return l.swapCard(userID, held[0].CardID, cardID, card)
+		}
+		l.notifyUser(userID, fmt.Sprintf("You can hold at most %d cards in this round.", maxCards))
+		return false
+	}
+
+	// Hold the stake for this card in escrow
+	hold, err := PlaceHold(userID, l.Stake, cardID, l.stakeAmount())
+	if err != nil {
+		l.holdRefused(userID, cardID, err)
 		return false
 	}
-	var numbers []int
+
+	// The card may have gone, or the round started, while the hold was placed
+	l.mu.Lock()
+	events, err := l.engine.Select(userID, cardID, card)
+	if err == nil {
+		l.holds[cardID] = hold.ID
+	}
+	l.mu.Unlock()
+	if err != nil {
+		if err := ReleaseHold(hold.ID); err != nil {
+			log.Printf("[Lobby %d] failed to release hold %d: %v", l.Stake, hold.ID, err)
+		}
+		log.Printf("[Lobby %d] User %d could not take card %d: %v", l.Stake, userID, cardID, err)
+		return false
+	}
+
+	log.Printf("[Lobby %d] User %d selected card %d", l.Stake, userID, cardID)
+	l.handle(events)
+	return true
+}
+
+// swapCard moves the user's hold from one card to another.
+func (l *Lobby) swapCard(userID uint, previous, cardID int, card game.Card) bool {
+	l.mu.Lock()
+	events, err := l.engine.Swap(userID, previous, cardID, card)
+	holdID := l.holds[previous]
+	if err == nil {
+		delete(l.holds, previous)
+		l.holds[cardID] = holdID
+	}
+	l.mu.Unlock()
+	if err != nil {
+		log.Printf("[Lobby %d] User %d could not swap card %d for %d: %v", l.Stake, userID, previous, cardID, err)
+		return false
+	}
+
+	if err := config.DB.Model(&models.StakeHold{}).Where("id = ?", holdID).Update("card_id", cardID).Error; err != nil {
+		log.Printf("[Lobby %d] failed to move hold %d to card %d: %v", l.Stake, holdID, cardID, err)
+	}
+	log.Printf("[Lobby %d] User %d swapped card %d for %d", l.Stake, userID, previous, cardID)
+	l.handle(events)
+	return true
+}
+
+// holdRefused tells the player why their stake couldn't be held.
+func (l *Lobby) holdRefused(userID uint, cardID int, err error) {
+	var limitErr *LimitError
+	var excluded *ExcludedError
+	if errors.Is(err, ErrInsufficientFunds) {
+		l.notifyUser(userID, "Insufficient balance to select this card.")
+		log.Printf("[Lobby %d] User %d cannot select card %d: insufficient balance", l.Stake, userID, cardID)
+	} else if errors.As(err, &excluded) {
+		l.notifyUser(userID, "🛑 "+excluded.Error())
+		log.Printf("[Lobby %d] User %d cannot select card %d: self-excluded", l.Stake, userID, cardID)
+	} else if errors.As(err, &limitErr) {
+		l.notifyUser(userID, "⛔ "+limitErr.Error())
+		log.Printf("[Lobby %d] User %d cannot select card %d: %s %s limit", l.Stake, userID, cardID, limitErr.Limit.Period, limitErr.Limit.Kind)
+	} else {
+		log.Printf("[Lobby %d] failed to hold stake for user %d: %v", l.Stake, userID, err)
+	}
+}
+
+// cardByID looks a card up in the loaded card set.
+func cardByID(cardID int) (game.Card, bool) {
 	cardsMu.RLock()
+	defer cardsMu.RUnlock()
 	for _, c := range Cards {
 		if c.CardID == cardID {
+			numbers := make([]int, 0, game.Size*game.Size)
 			numbers = append(numbers, c.B...)
 			numbers = append(numbers, c.I...)
 			numbers = append(numbers, c.N...)
 			numbers = append(numbers, c.G...)
 			numbers = append(numbers, c.O...)
-			break
+			return game.NewCard(numbers)
 		}
 	}
-	cardsMu.RUnlock()
+	return game.Card{}, false
+}
 
-	if len(numbers) == 0 {
-		log.Printf("[Lobby %d] invalid cardID %d", l.Stake, cardID)
+// DeselectCard gives up one of the user's cards before the round starts,
+// or all of them when cardID is 0, and releases the held stakes.
+func (l *Lobby) DeselectCard(userID uint, cardID int) bool {
+	l.mu.Lock()
+	var events []game.Event
+	var err error
+	if cardID == 0 {
+		events, err = l.engine.DeselectAll(userID)
+	} else {
+		events, err = l.engine.Deselect(userID, cardID)
+	}
+	l.mu.Unlock()
+	if err != nil {
+		log.Printf("[Lobby %d] User %d could not deselect: %v", l.Stake, userID, err)
 		return false
 	}
 
-	// Step 2: Lock the lobby to safely update internal state
+	log.Printf("[Lobby %d] User %d deselected card", l.Stake, userID)
+	l.handle(events)
+	return true
+}
+
+// releaseCard gives back the stake held for a card that left the round.
+func (l *Lobby) releaseCard(ev game.CardReleased) {
 	l.mu.Lock()
-	defer l.mu.Unlock()
+	holdID, ok := l.holds[ev.CardID]
+	delete(l.holds, ev.CardID)
+	l.mu.Unlock()
+	if !ok {
+		return
+	}
+	if err := ReleaseHold(holdID); err != nil && !errors.Is(err, ErrHoldNotActive) {
+		log.Printf("[Lobby %d] failed to release hold %d for user %d: %v", l.Stake, holdID, ev.Player, err)
+	}
+}
 
-	// Check if card selection is allowed
-	if !l.canSelectCard() {
-		log.Printf("[Lobby %d] User %d tried to select card %d but round in progress", l.Stake, userID, cardID)
-		return false
+// CheckBingo judges the user's claim on one card, or on every card they
+// haven't checked yet when cardID is 0. Each valid card comes back with the
+// pattern, cells and ball it won with.
+func (l *Lobby) CheckBingo(userID uint, cardID int) ([]RoundWinner, bool) {
+	l.mu.Lock()
+	events, err := l.engine.Claim(userID, cardID)
+	l.mu.Unlock()
+
+	var claims []RoundWinner
+	for _, ev := range events {
+		if ev, ok := ev.(game.ClaimAccepted); ok {
+			claims = append(claims, roundWinner(ev.Winner))
+		}
 	}
 
-	// Check if the card is already taken
-	if l.selectedIDs[cardID] {
-		log.Printf("[Lobby %d] Card %d already taken", l.Stake, cardID)
-		return false
+	switch {
+	case err == nil:
+		log.Printf("[Lobby %d] User %d claims BINGO on %d card(s)!", l.Stake, userID, len(claims))
+	case errors.Is(err, game.ErrAlreadyClaimed):
+		l.notifyUser(userID, "⚠️ You cannot check this card again this round.ይሄ ካርቴላ ታስረዋል.")
+		log.Printf("[Lobby %d] User %d already checked card %d this round", l.Stake, userID, cardID)
+	case errors.Is(err, game.ErrClaimsClosed):
+		l.notifyUser(userID, "⏱ Too late, the winners of this round have already been decided.")
+	case errors.Is(err, game.ErrNoCard):
+		log.Printf("[Lobby %d] User %d tried Bingo without a card", l.Stake, userID)
+	case errors.Is(err, game.ErrNoBingo):
+		log.Printf("[Lobby %d] User %d checked Bingo and failed", l.Stake, userID)
 	}
+	l.handle(events)
+	return claims, err == nil
+}
 
-	// Update lobby maps
-	l.Cards[userID] = numbers
-	l.CardIDs[userID] = cardID
-	l.selectedIDs[cardID] = true
+// -------------------- Round driver --------------------
 
-	log.Printf("[Lobby %d] User %d selected card %d", l.Stake, userID, cardID)
+// RunAutoRounds advances the round clock for as long as the server runs.
+// A new countdown opens whenever the lobby is waiting.
+func (l *Lobby) RunAutoRounds() {
+	ticker := time.NewTicker(lobbyTick)
+	defer ticker.Stop()
+	for range ticker.C {
+		l.advance()
+	}
+}
+
+func (l *Lobby) advance() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[Lobby %d] round driver panic: %v", l.Stake, r)
+		}
+	}()
 
-	// Step 3: Unlock before broadcasting to prevent deadlocks
+	l.mu.RLock()
+	waiting := l.engine.Phase() == game.Waiting
+	l.mu.RUnlock()
+	if waiting {
+		l.openCountdown()
+	}
+
+	l.mu.Lock()
+	events := l.engine.Tick()
 	l.mu.Unlock()
+	l.handle(events)
+}
+
+// openCountdown loads the lobby settings the next round will use and opens
+// its countdown.
+func (l *Lobby) openCountdown() {
+	settings, err := LobbySettingsFor(l.Stake)
+	if err != nil {
+		log.Printf("[Lobby %d] failed to load settings, using defaults: %v", l.Stake, err)
+		settings = models.DefaultLobbySettings(l.Stake)
+	}
+	patterns, err := ResolvePatterns(settings.Patterns)
+	if err != nil {
+		log.Printf("[Lobby %d] failed to load patterns, using the classic set: %v", l.Stake, err)
+	}
+	// Every round is drawn from seeds committed to before it starts
+	fair, err := game.NewFairDraw()
+	if err != nil {
+		log.Printf("[Lobby %d] failed to pick a server seed: %v", l.Stake, err)
+		return
+	}
+
+	l.mu.Lock()
+	if l.nextPatterns != nil {
+		patterns = l.nextPatterns
+	}
+	events, err := l.engine.OpenCountdown(game.Rules{
+		ClaimWindow:     time.Duration(settings.ClaimWindowMS) * time.Millisecond,
+		JackpotMaxBalls: jackpotMaxBalls(settings),
+		Patterns:        patterns,
+		MaxCards:        settings.MaxCards,
+		Draw:            fair,
+	})
+	if err == nil {
+		l.settings = settings
+		l.nextPatterns = nil
+		l.fair = fair
+	}
+	l.mu.Unlock()
+	l.handle(events)
+}
+
+// AddClientSeed mixes the user's seed into the draw of the round counting
+// down. Seeds can only be sent before the round starts.
+func (l *Lobby) AddClientSeed(userID uint, seed string) bool {
+	l.mu.Lock()
+	err := game.ErrWrongPhase
+	if l.fair != nil && l.engine.Phase() == game.Countdown {
+		err = l.fair.AddClientSeed(userID, seed)
+	}
+	l.mu.Unlock()
+	if err != nil {
+		l.notifyUser(userID, "Your seed was not used: "+err.Error())
+		return false
+	}
+	log.Printf("[Lobby %d] User %d contributed a client seed", l.Stake, userID)
 	l.broadcastState()
-	l.mu.Lock() // relock to satisfy defer
 	return true
 }
 
-func (l *Lobby) CheckBingo(userID uint) bool {
-	// --- Step 1: Initialize CheckedUsers map and check if user already checked ---
+// SetNextRoundPatterns makes the next countdown to open play the named
+// patterns instead of the lobby's own. A countdown already open keeps the
+// patterns it announced.
+func (l *Lobby) SetNextRoundPatterns(names []string) ([]game.Pattern, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%w: no patterns given", ErrUnknownPattern)
+	}
+	patterns, err := ResolvePatterns(names)
+	if err != nil {
+		return nil, err
+	}
 	l.mu.Lock()
-	if l.CheckedUsers == nil {
-		l.CheckedUsers = make(map[uint]bool)
+	l.nextPatterns = patterns
+	l.mu.Unlock()
+	return patterns, nil
+}
+
+func jackpotMaxBalls(s models.LobbySettings) int {
+	if !s.JackpotOn() {
+		return 0
 	}
+	return s.JackpotMaxBalls
+}
 
-	if l.CheckedUsers[userID] {
-		l.mu.Unlock() // unlock first
-		l.notifyUser(userID, "⚠️ You cannot check again this round.ይሄ ካርቴላ ታስረዋል.")
-		log.Printf("[Lobby %d] User %d already checked Bingo this round", l.Stake, userID)
-		return false
+// handle acts on the events of one engine call and then updates players.
+// Round events only come from Tick, so they are handled on the driver
+// goroutine one at a time.
+func (l *Lobby) handle(events []game.Event) {
+	if len(events) == 0 {
+		return
 	}
+	for _, ev := range events {
+		switch ev := ev.(type) {
+		case game.RoundStarted:
+			l.startRound(ev)
+		case game.BallDrawn:
+			l.saveNumbers()
+		case game.CardReleased:
+			l.releaseCard(ev)
+		case game.ClaimAccepted:
+			l.sendClaim(ev.Winner)
+		case game.ClaimsClosed:
+			if len(ev.Winners) > 0 {
+				l.settleClaims(ev.Winners)
+			}
+		case game.RoundEnded:
+			l.endRound(ev)
+		}
+	}
+	l.broadcastState()
+}
 
-	// Mark as checked
-	l.CheckedUsers[userID] = true
+// startRound creates the game and moves every held stake into its pot.
+// Cards whose stake can't be taken are dropped from the round.
+func (l *Lobby) startRound(ev game.RoundStarted) {
+	l.mu.RLock()
+	settings := l.settings
+	fair := l.fair
+	l.mu.RUnlock()
 
-	// Copy numbers and drawn numbers safely
-	numbers, ok := l.Cards[userID]
-	drawnNums := append([]string(nil), l.NumbersDrawn...)
-	l.mu.Unlock() // unlock ASAP
+	var lastGame models.Game
+	result := config.DB.Where("stake = ?", l.Stake).Order("round_number DESC").First(&lastGame)
+	nextRound := 1
+	if result.Error == nil {
+		nextRound = lastGame.RoundNumber + 1
+	}
 
-	// --- Step 2: Validate user has a card ---
-	if !ok {
-		log.Printf("[Lobby %d] User %d tried Bingo without a card", l.Stake, userID)
-		return false
+	game := models.Game{
+		Stake:       l.Stake,
+		Status:      "in_progress",
+		StartTime:   time.Now(),
+		RoundNumber: nextRound,
+		NumbersJSON: datatypes.JSON([]byte("[]")),
+		PayoutBps:   settings.PayoutBps,
+		RakeBps:     settings.RakeBps,
+	}
+	if fair != nil {
+		game.ServerSeedHash = fair.Commitment()
+		game.ServerSeed = fair.ServerSeed
+		game.ClientSeed = fair.ClientSeed()
+	}
+	if err := config.DB.Create(&game).Error; err != nil {
+		log.Printf("[Lobby %d] failed to create game, aborting round: %v", l.Stake, err)
+		l.releaseAllHolds()
+		l.mu.Lock()
+		events, _ := l.engine.Void("could not create game")
+		l.mu.Unlock()
+		l.handle(events)
+		return
 	}
 
-	log.Printf("[Lobby %d] checking bingo for user %d", l.Stake, userID)
+	l.mu.Lock()
+	l.currentGame = &game
+	heldFor := make(map[int]uint, len(l.holds)) // cardID -> holdID
+	for cardID, holdID := range l.holds {
+		heldFor[cardID] = holdID
+	}
+	l.mu.Unlock()
+
+	paid := 0
+	for cardID, userID := range ev.Cards {
+		if err := CaptureHold(heldFor[cardID], game.ID); err != nil {
+			log.Printf("[Lobby %d] failed to capture hold %d for user %d: %v", l.Stake, heldFor[cardID], userID, err)
+			l.notifyUser(userID, fmt.Sprintf("We could not take your stake for card %d. It has been removed from this round.", cardID))
+			l.mu.Lock()
+			events, err := l.engine.Remove(userID, cardID)
+			l.mu.Unlock()
+			if err != nil {
+				log.Printf("[Lobby %d] failed to drop card %d of user %d: %v", l.Stake, cardID, userID, err)
+			}
+			l.handle(events)
+			continue
+		}
+		paid++
+	}
+	stakes := l.stakeAmount().Mul(paid) // only cards that paid
 
-	// --- Step 3: Build drawn set ---
-	drawnSet := make(map[int]bool, len(drawnNums))
-	for _, n := range drawnNums {
-		if num, err := strconv.Atoi(n); err == nil {
-			drawnSet[num] = true
+	// The house share is booked up front; the winner's prize comes out of
+	// what is left, and anything left after that is swept at the end
+	if err := bookRake(game.ID, stakes.Percent(int64(settings.RakeBps))); err != nil {
+		log.Printf("[Lobby %d] failed to book rake for game %d: %v", l.Stake, game.ID, err)
+	}
+	if settings.JackpotOn() {
+		if err := feedJackpot(game.ID, l.Stake, stakes.Percent(int64(settings.JackpotBps))); err != nil {
+			log.Printf("[Lobby %d] failed to feed jackpot from game %d: %v", l.Stake, game.ID, err)
 		}
+		l.refreshJackpot()
 	}
 
-	// --- Step 4: Build 5x5 grid ---
-	grid := make([][]int, 5)
-	for i := 0; i < 5; i++ {
-		grid[i] = numbers[i*5 : (i+1)*5]
+	l.mu.Lock()
+	if stakes > 0 {
+		l.roundPot = settings.Prize(stakes)
 	}
+	l.mu.Unlock()
+}
 
-	// --- Step 5: Check bingo patterns ---
-	if hasBingo(grid, drawnSet) {
-		log.Printf("[Lobby %d] User %d claims BINGO!", l.Stake, userID)
-        // Stop number drawing immediately
-    l.mu.Lock()
-    if l.drawCancel != nil {
-        close(l.drawCancel)           // signal cancel
-       l.drawCancel = nil // recreate for next round
-    }
-		// --- Store winner safely ---
-		
-		l.BingoWinner = &userID
-		if cid, ok := l.CardIDs[userID]; ok {
-			l.BingoWinnerCardID = &cid
-		}
-		joinedUsers := len(l.Cards)
-		l.mu.Unlock()
+// saveNumbers stores the balls drawn so far on the game. Only the numbers;
+// the status belongs to endRound and VoidGame.
+func (l *Lobby) saveNumbers() {
+	l.mu.RLock()
+	current := l.currentGame
+	drawn := l.engine.Snapshot().Drawn
+	l.mu.RUnlock()
+	if current == nil {
+		return
+	}
+	jsonBytes, err := json.Marshal(ballStrings(drawn))
+	if err != nil {
+		return
+	}
+	if err := config.DB.Model(&models.Game{}).Where("id = ?", current.ID).
+		Update("numbers_json", datatypes.JSON(jsonBytes)).Error; err != nil {
+		log.Printf("[Lobby %d] failed to save numbers of game %d: %v", l.Stake, current.ID, err)
+	}
+}
+
+func ballStrings(balls []int) []string {
+	out := make([]string, len(balls))
+	for i, b := range balls {
+		out[i] = strconv.Itoa(b)
+	}
+	return out
+}
 
-		// --- Payout ---
-		totalPot := float64(l.Stake * joinedUsers)
-		winnings := totalPot * 0.8
+// endRound closes the game of a finished round and clears the lobby for the
+// next one. Rounds without a winner leave their stakes in the pot to be
+// swept; voided rounds have already been refunded.
+func (l *Lobby) endRound(ev game.RoundEnded) {
+	l.mu.RLock()
+	current := l.currentGame
+	l.mu.RUnlock()
+	if current != nil && !ev.Voided && current.Status != "voided" {
+		l.finishGame(current.ID)
+	}
 
-		// Async DB update, notification, broadcast
-		go l.handleBingoWinner(userID, winnings)
+	l.mu.Lock()
+	l.holds = make(map[int]uint)
+	l.currentGame = nil
+	l.paid = nil
+	l.unpaid = nil
+	l.roundPot = 0
+	l.fair = nil
+	l.mu.Unlock()
+}
 
-		// End round after slight delay
-		go func() {
-			time.Sleep(7 * time.Second)
-			l.endRound()
-		}()
+// finishGame closes a settled game. A game whose winners couldn't be paid
+// is never closed, as that would sweep their prize into rake: the payout is
+// tried once more, and if it still fails the game is voided and every stake
+// refunded. If even that fails the game stays in_progress for
+// RecoverInterruptedRounds.
+func (l *Lobby) finishGame(gameID uint) {
+	l.mu.RLock()
+	unpaid := l.unpaid
+	settings := l.settings
+	l.mu.RUnlock()
 
-		return true
+	if len(unpaid) > 0 {
+		err := payRound(gameID, settings, unpaid)
+		if err == nil {
+			l.announcePaid(settings, unpaid)
+		} else {
+			log.Printf("[Lobby %d] failed again to pay winners of game %d: %v", l.Stake, gameID, err)
+			refunded, err := voidRound(gameID, "winners could not be paid")
+			if err != nil {
+				log.Printf("[Lobby %d] failed to void unpaid game %d, left for recovery: %v", l.Stake, gameID, err)
+				return
+			}
+			log.Printf("[Lobby %d] voided unpaid game %d, refunded %d entries", l.Stake, gameID, refunded)
+			for _, w := range unpaid {
+				l.notifyUser(w.UserID, "⚠️ Your winnings could not be paid, so the round was cancelled and every stake refunded.")
+			}
+			return
+		}
 	}
 
-	// ❌ Bingo failed, user is already marked as checked
-	log.Printf("[Lobby %d] User %d checked Bingo and failed", l.Stake, userID)
-	return false
+	if err := closeRound(gameID); err != nil {
+		log.Printf("[Lobby %d] failed to close game %d: %v", l.Stake, gameID, err)
+	}
 }
 
+// Settlement steps that touch the ledger; tests replace them.
+var (
+	payRound   = payWinners
+	voidRound  = VoidGame
+	closeRound = closeSettledGame
+)
+
 // -----------------
-// Extracted helper
+// Ledger postings
 // -----------------
-func hasBingo(grid [][]int, drawnSet map[int]bool) bool {
-	const freeRow, freeCol = 2, 2 // center free space
 
-	isDrawn := func(n int, row, col int) bool {
-		if row == freeRow && col == freeCol {
-			return true
+// releaseAllHolds gives every held stake back, e.g. when a round can't start.
+func (l *Lobby) releaseAllHolds() {
+	l.mu.Lock()
+	holds := l.holds
+	l.holds = make(map[int]uint)
+	l.mu.Unlock()
+
+	for cardID, holdID := range holds {
+		if err := ReleaseHold(holdID); err != nil {
+			log.Printf("[Lobby %d] failed to release hold %d for card %d: %v", l.Stake, holdID, cardID, err)
 		}
-		return drawnSet[n]
 	}
+}
 
-	checkLine := func(cells [][2]int) bool {
-		for _, cell := range cells {
-			row, col := cell[0], cell[1]
-			if !isDrawn(grid[row][col], row, col) {
-				return false
-			}
+// sweepPot books whatever is left in a game pot as house rake.
+func sweepPot(gameID uint) error {
+	return WalletTx(config.DB, func(tx *gorm.DB) error {
+		pot, err := GamePotAccount(tx, gameID)
+		if err != nil || pot.Balance <= 0 {
+			return err
 		}
-		return true
+		house, err := SystemAccount(tx, HouseRakeCode, models.HouseRakeAccount)
+		if err != nil {
+			return err
+		}
+		entry := &models.JournalEntry{Kind: models.RakeEntry, GameID: &gameID}
+		return Transfer(tx, entry, pot, house, pot.Balance)
+	})
+}
+
+// settleClaims splits the prize between everyone who claimed in the claim
+// window and pays them.
+func (l *Lobby) settleClaims(claims []game.Winner) {
+	l.mu.RLock()
+	prize := l.roundPot
+	settings := l.settings
+	var gameID uint
+	if l.currentGame != nil {
+		gameID = l.currentGame.ID
 	}
+	l.mu.RUnlock()
 
-	// 1️⃣ Corners
-	corners := [][2]int{{0, 0}, {0, 4}, {4, 0}, {4, 4}}
-	if checkLine(corners) {
-		return true
+	winners := make([]RoundWinner, len(claims))
+	shares := settings.SplitPrize(prize, len(claims))
+	for i, c := range claims {
+		winners[i] = roundWinner(c)
+		winners[i].Prize = shares[i]
 	}
 
-	// 2️⃣ Horizontal lines
-	for row := 0; row < 5; row++ {
-		cells := [][2]int{}
-		for col := 0; col < 5; col++ {
-			cells = append(cells, [2]int{row, col})
+	if err := payRound(gameID, settings, winners); err != nil {
+		// The game stays unsettled; endRound retries the payout.
+		log.Printf("[Lobby %d] failed to pay winners of game %d: %v", l.Stake, gameID, err)
+		l.mu.Lock()
+		l.unpaid = winners
+		l.mu.Unlock()
+		return
+	}
+	l.announcePaid(settings, winners)
+}
+
+// announcePaid tells each paid winner what they won and keeps the winners,
+// with names and prizes, for the broadcast.
+func (l *Lobby) announcePaid(settings models.LobbySettings, winners []RoundWinner) {
+	for i, w := range winners {
+		var user models.User
+		if err := config.DB.First(&user, w.UserID).Error; err == nil {
+			winners[i].Name = user.Name
+		} else {
+			log.Printf("[Lobby %d] failed to fetch winner user %d: %v", l.Stake, w.UserID, err)
 		}
-		if checkLine(cells) {
-			return true
+		msg := fmt.Sprintf("🎉 You won BINGO with %s! Winnings: %s", w.label, w.Prize)
+		if len(winners) > 1 {
+			msg = fmt.Sprintf("🎉 You won BINGO with %s! The pot is shared by %d winners. Winnings: %s", w.label, len(winners), w.Prize)
 		}
+		if w.Jackpot > 0 {
+			msg += fmt.Sprintf("\n💰 JACKPOT! Full card in %d balls: +%s", settings.JackpotMaxBalls, w.Jackpot)
+		}
+		l.notifyUser(w.UserID, msg)
 	}
 
-	// 3️⃣ Vertical lines
-	for col := 0; col < 5; col++ {
-		cells := [][2]int{}
-		for row := 0; row < 5; row++ {
-			cells = append(cells, [2]int{row, col})
+	l.mu.Lock()
+	l.paid = winners
+	l.unpaid = nil
+	l.mu.Unlock()
+
+	l.refreshJackpot()
+}
+
+// payWinners pays every winner's share from the pot, and the jackpot to
+// those who qualified, and stores the winning lines on the game, in one
+// transaction, so a recovered game is either fully paid or not paid at all.
+func payWinners(gameID uint, settings models.LobbySettings, winners []RoundWinner) error {
+	return WalletTx(config.DB, func(tx *gorm.DB) error {
+		pot, err := GamePotAccount(tx, gameID)
+		if err != nil {
+			return err
 		}
-		if checkLine(cells) {
-			return true
+		var total models.Money
+		for _, w := range winners {
+			total += w.Prize
+		}
+		if err := subsidizePot(tx, gameID, pot, total); err != nil {
+			return err
 		}
-	}
 
-	// 4️⃣ Cross (middle row + middle column)
-	cross := [][2]int{}
-	for i := 0; i < 5; i++ {
-		cross = append(cross, [2]int{2, i}) // middle row
-		cross = append(cross, [2]int{i, 2}) // middle column
+		for _, w := range winners {
+			if err := payWinner(tx, gameID, pot, w); err != nil {
+				return err
+			}
+		}
+		if err := payJackpot(tx, gameID, settings, winners); err != nil {
+			return err
+		}
+
+		// Keep the winning lines with the game, to settle disputes
+		jsonBytes, err := json.Marshal(winners)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Game{}).Where("id = ?", gameID).Update("winning_json", datatypes.JSON(jsonBytes)).Error
+	})
+}
+
+// payWinner pays one winner's prize. The part won with bonus-funded stakes
+// follows the promotion's winnings rule; the rest is cash.
+func payWinner(tx *gorm.DB, gameID uint, pot *models.LedgerAccount, w RoundWinner) error {
+	userID := w.UserID
+	wallet, err := WalletAccount(tx, userID)
+	if err != nil {
+		return err
 	}
-	if checkLine(cross) {
-		return true
+	bonus, err := BonusWalletAccount(tx, userID)
+	if err != nil {
+		return err
 	}
-
-	// 5️⃣ Diagonals
-	diag1 := [][2]int{}
-	diag2 := [][2]int{}
-	for i := 0; i < 5; i++ {
-		diag1 = append(diag1, [2]int{i, i})
-		diag2 = append(diag2, [2]int{i, 4 - i})
+	toBonus, err := bonusShareOf(tx, gameID, w.CardID, w.Prize)
+	if err != nil {
+		return err
 	}
-	if checkLine(diag1) || checkLine(diag2) {
-		return true
+	if toBonus > 0 {
+		rule, err := bonusWinningsRule(tx, userID)
+		if err != nil {
+			return err
+		}
+		if rule == models.WinningsToCash {
+			toBonus = 0
+		}
 	}
+	cash := w.Prize - toBonus
 
-	// 6️⃣ Full card
-	fullCard := [][2]int{}
-	for r := 0; r < 5; r++ {
-		for c := 0; c < 5; c++ {
-			fullCard = append(fullCard, [2]int{r, c})
+	entry := &models.JournalEntry{Kind: models.PayoutEntry, UserID: &userID, GameID: &gameID}
+	if err := PostEntry(tx, entry, bucketLegs(wallet, bonus, pot, cash, toBonus, 1)...); err != nil {
+		return err
+	}
+	if cash > 0 {
+		if err := recordTransaction(tx, wallet, models.WinTransaction, cash, &gameID, ""); err != nil {
+			return err
 		}
 	}
-	if checkLine(fullCard) {
-		return true
+	if toBonus > 0 {
+		if err := recordTransaction(tx, bonus, models.BonusWinTransaction, toBonus, &gameID, ""); err != nil {
+			return err
+		}
 	}
-
-	return false
+	return markWinningEntry(tx, gameID, w.CardID)
 }
 
-// -----------------
-// Async handler
-// -----------------
-func (l *Lobby) handleBingoWinner(userID uint, winnings float64) {
-	log.Printf("db save")
-	// Update balance
-	var winner models.User
-	if err := config.DB.First(&winner, userID).Error; err == nil {
-		winner.Balance += winnings
-		if err := config.DB.Save(&winner).Error; err != nil {
-			log.Printf("[Lobby %d] failed to update balance for user %d: %v", l.Stake, userID, err)
-		} else {
-			l.notifyUser(userID, fmt.Sprintf("🎉 You won BINGO! Winnings: %.2f", winnings))
-			// ✅ Save winner name for broadcast
-			l.mu.Lock()
-			l.BingoWinnerName = &winner.Name
-			log.Printf("%s", *l.BingoWinnerName)
-			l.mu.Unlock()
+// NotifyUser sends a notification to the player in every lobby they are
+// connected to, e.g. for actions taken outside the WebSocket.
+func NotifyUser(userID uint, message string) {
+	LobbiesMu.Lock()
+	lobbies := make([]*Lobby, 0, len(Lobbies))
+	for _, l := range Lobbies {
+		lobbies = append(lobbies, l)
+	}
+	LobbiesMu.Unlock()
+
+	for _, l := range lobbies {
+		l.mu.RLock()
+		_, connected := l.clients[userID]
+		l.mu.RUnlock()
+		if connected {
+			l.notifyUser(userID, message)
 		}
-	} else {
-		log.Printf("[Lobby %d] failed to fetch winner user %d: %v", l.Stake, userID, err)
 	}
+}
 
-	// Broadcast state (async, doesn’t block CheckBingo)
-	l.broadcastState()
+// DisconnectUser tells the player why and then drops them from every lobby,
+// releasing stakes held for a round that hasn't started.
+func DisconnectUser(userID uint, message string) {
+	LobbiesMu.Lock()
+	lobbies := make([]*Lobby, 0, len(Lobbies))
+	for _, l := range Lobbies {
+		lobbies = append(lobbies, l)
+	}
+	LobbiesMu.Unlock()
+
+	for _, l := range lobbies {
+		l.mu.RLock()
+		_, connected := l.clients[userID]
+		l.mu.RUnlock()
+		if !connected {
+			continue
+		}
+		l.notifyUser(userID, message)
+		go func(l *Lobby) {
+			time.Sleep(time.Second) // let the notification reach the player
+			l.removeClient(userID)
+		}(l)
+	}
 }
 
 func (l *Lobby) notifyUser(userID uint, message string) {
+	l.send(userID, map[string]string{
+		"type":    "notification",
+		"message": message,
+	})
+}
+
+// sendClaim tells a player their bingo was accepted and what it won with.
+func (l *Lobby) sendClaim(w game.Winner) {
+	l.send(w.Player, struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+		RoundWinner
+	}{
+		Type:        "bingo",
+		Message:     fmt.Sprintf("✅ BINGO with %s! Winners are paid when the claim window closes.", w.Pattern.Label),
+		RoundWinner: roundWinner(w),
+	})
+}
+
+func (l *Lobby) send(userID uint, payload any) {
 	l.mu.RLock()
 	client, ok := l.clients[userID]
 	l.mu.RUnlock()
@@ -386,11 +875,6 @@ func (l *Lobby) notifyUser(userID uint, message string) {
 		return
 	}
 
-	payload := map[string]string{
-		"type":    "notification",
-		"message": message,
-	}
-
 	b, _ := json.Marshal(payload)
 
 	select {
@@ -400,215 +884,143 @@ func (l *Lobby) notifyUser(userID uint, message string) {
 	}
 }
 
-// -------------------- Auto Rounds --------------------
-func (l *Lobby) RunAutoRounds() {
-	for {
-		// Skip if round already in progress
-		l.mu.RLock()
-		inProgress := l.Status == "in_progress"
-		l.mu.RUnlock()
-		if inProgress {
-			time.Sleep(500 * time.Millisecond)
-			continue
-		}
-
-		// Countdown
-		l.mu.Lock()
-		l.Status = "countdown"
-		l.Countdown = DefaultCountdownSec
-		l.mu.Unlock()
-		l.broadcastState()
+// ErrRoundNotRunning is returned when a lobby isn't running the given game.
+var ErrRoundNotRunning = errors.New("round is not running in this lobby")
 
-		for i := DefaultCountdownSec; i > 0; i-- {
-			l.mu.Lock()
-			l.Countdown = i
-			l.mu.Unlock()
-			l.broadcastState()
-			time.Sleep(1 * time.Second)
-		}
-
-		// ✅ Require at least 2 selected cards
-		l.mu.RLock()
-		cardCount := len(l.CardIDs)
-		l.mu.RUnlock()
+// ErrRoundSettling is returned when a round already has a winner being paid.
+var ErrRoundSettling = errors.New("round already has a winner")
 
-		if cardCount < 1 {
-
-			l.mu.Lock()
-			l.Status = "waiting"
-			l.Countdown = DefaultCountdownSec
-			l.mu.Unlock()
-			l.broadcastState()
-			continue // skip starting the round
-		}
-
-		// Start round safely
-		l.startRound()
-
-		// Wait for round to finish
-		<-l.roundDone
+// CurrentGameID returns the game the lobby is running, if any.
+func (l *Lobby) CurrentGameID() (uint, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	phase := l.engine.Phase()
+	if l.currentGame == nil || (phase != game.Drawing && phase != game.Claiming) {
+		return 0, false
 	}
+	return l.currentGame.ID, true
 }
 
-func (l *Lobby) startRound() {
-	// 1️⃣ Set round status
+// AbortRound stops the draw and refunds every paid card. The round is shown
+// as cancelled until the next countdown.
+func (l *Lobby) AbortRound(gameID uint, reason string) (int, error) {
 	l.mu.Lock()
-	l.Status = "in_progress"
-	 l.drawCancel = make(chan struct{})
-	l.NumbersDrawn = []string{}
-	l.CheckedUsers = make(map[uint]bool) // ✅ reset checked users
-	joinedUsers := len(l.Cards) // number of users at start
-    l.roundPot = float64(l.Stake * joinedUsers) * 0.8
-	l.mu.Unlock()
-	l.broadcastState()
-
-	// 1.5️⃣ Deduct stake from all users who selected a card
-	l.mu.RLock()
-	selectedUsers := make(map[uint]int, len(l.CardIDs)) // userID -> cardID
-	for userID, cardID := range l.CardIDs {
-		selectedUsers[userID] = cardID
+	if l.currentGame == nil || l.currentGame.ID != gameID {
+		l.mu.Unlock()
+		return 0, ErrRoundNotRunning
 	}
-	l.mu.RUnlock()
-
-	for userID, cardID := range selectedUsers {
-		var user models.User
-		if err := config.DB.First(&user, userID).Error; err != nil {
-			log.Printf("[Lobby %d] failed to fetch user %d for stake deduction: %v", l.Stake, userID, err)
-			continue
-		}
-
-		if user.Balance >= float64(l.Stake) {
-			user.Balance -= float64(l.Stake)
-			if err := config.DB.Save(&user).Error; err != nil {
-				log.Printf("[Lobby %d] failed to deduct stake from user %d: %v", l.Stake, userID, err)
-				continue
-			}
-
-			// Notify the user
-			//l.notifyUser(userID, fmt.Sprintf("Your stake of %d has been deducted for this round.", l.Stake))
-		} else {
-			log.Printf("[Lobby %d] user %d has insufficient balance during startRound", l.Stake, userID)
-			l.notifyUser(userID, "Insufficient balance for this round. Your card has been removed.")
-
-			// Remove card safely
-			l.mu.Lock()
-			delete(l.Cards, userID)
-			delete(l.CardIDs, userID)
-			delete(l.selectedIDs, cardID)
-			l.mu.Unlock()
+	events, err := l.engine.Void(reason)
+	if err != nil {
+		l.mu.Unlock()
+		if errors.Is(err, game.ErrHasWinners) {
+			return 0, ErrRoundSettling
 		}
+		return 0, ErrRoundNotRunning
 	}
+	state := l.engine.Snapshot()
+	l.mu.Unlock()
 
-	// 2️⃣ Create a new game
-	var lastGame models.Game
-	result := config.DB.Where("stake = ?", l.Stake).Order("round_number DESC").First(&lastGame)
-	nextRound := 1
-	if result.Error == nil {
-		nextRound = lastGame.RoundNumber + 1
+	refunded, err := VoidGame(gameID, reason)
+	if err != nil {
+		// Leave the game in_progress so it can be voided again, or recovered
+		// at the next startup, instead of sweeping the stakes as rake
+		log.Printf("[Lobby %d] failed to void game %d: %v", l.Stake, gameID, err)
 	}
 
-	game := models.Game{
-		Stake:       l.Stake,
-		Status:      "in_progress",
-		StartTime:   time.Now(),
-		RoundNumber: nextRound,
-		NumbersJSON: datatypes.JSON([]byte("[]")),
+	l.mu.Lock()
+	if l.currentGame != nil {
+		l.currentGame.Status = "voided"
 	}
+	l.mu.Unlock()
 
-	if err := config.DB.Create(&game).Error; err != nil {
-		log.Printf("[Lobby %d] failed to create game: %v", l.Stake, err)
-	} else {
-		l.mu.Lock()
-		l.currentGame = &game
-		l.mu.Unlock()
+	if err == nil {
+		for userID := range state.Cards {
+			l.notifyUser(userID, "⚠️ This round was cancelled. Your stake has been refunded.")
+		}
 	}
+	l.handle(events)
+	return refunded, err
+}
 
-	// 3️⃣ Draw numbers in a goroutine
-	go func() {
-    defer func() {
-        if r := recover(); r != nil {
-            log.Printf("[Lobby %d] startRound panic: %v", l.Stake, r)
-        }
-        // endRound will be called by CheckBingo, so no need here
-		
-    }()
-
-    numbers := generateBingoNumbers()
-
-    for _, n := range numbers {
-        select {
-        case <-l.drawCancel:
-            log.Printf("[Lobby %d] Number draw canceled", l.Stake)
-            return // stop drawing numbers
-        case <-time.After(6 * time.Second):
-            l.mu.Lock()
-            l.NumbersDrawn = append(l.NumbersDrawn, strconv.Itoa(n))
-
-            if l.currentGame != nil {
-                if jsonBytes, err := json.Marshal(l.NumbersDrawn); err == nil {
-                    l.currentGame.NumbersJSON = datatypes.JSON(jsonBytes)
-                    _ = config.DB.Save(l.currentGame).Error
-                }
-            }
-            l.mu.Unlock()
-
-            // Broadcast after unlocking to avoid deadlock
-            l.broadcastState()
-        }
-    }
-	l.endRound()
-}()
+// -------------------- Broadcast --------------------
 
+// RoundWinner is one player sharing the pot, with the line they won with.
+// Name and Prize are filled in once the claim window has closed and the
+// winners have been paid.
+type RoundWinner struct {
+	UserID   uint         `json:"userId"`
+	CardID   int          `json:"cardId"`
+	Pattern  string       `json:"pattern"` // name of the pattern the card won with
+	Cells    []game.Cell  `json:"cells"`   // the squares of the winning pattern
+	Marked   []string     `json:"marked"`  // every covered square, drawn as mask rows
+	Ball     int          `json:"ball"`    // the drawn ball that completed the pattern
+	Name     string       `json:"name,omitempty"`
+	Prize    models.Money `json:"prize,omitempty"`
+	Jackpot  models.Money `json:"jackpot,omitempty"`
+	fullCard bool         // qualified for the jackpot when claiming
+	label    string       // the pattern's label, for messages
+}
 
+func roundWinner(w game.Winner) RoundWinner {
+	return RoundWinner{
+		UserID:   w.Player,
+		CardID:   w.CardID,
+		Pattern:  w.Pattern.Name,
+		Cells:    w.Cells,
+		Marked:   w.Marked.Rows(),
+		Ball:     w.Ball,
+		fullCard: w.FullCard,
+		label:    w.Pattern.Label,
+	}
 }
 
-func (l *Lobby) endRound() {
+// refreshJackpot reloads the cached jackpot balance shown to players.
+func (l *Lobby) refreshJackpot() {
+	balance, err := JackpotBalance(l.Stake)
+	if err != nil {
+		log.Printf("[Lobby %d] failed to load jackpot: %v", l.Stake, err)
+		return
+	}
 	l.mu.Lock()
-	if l.currentGame != nil {
-		l.currentGame.Status = "finished"
-		l.currentGame.EndTime = time.Now()
-		_ = config.DB.Save(l.currentGame).Error
-	}
-	if l.drawCancel != nil {
-        close(l.drawCancel)
-        l.drawCancel = nil
-    }
-	log.Printf("ending")
-	// Reset state
-	l.Cards = make(map[uint][]int)
-	l.CardIDs = make(map[uint]int)
-	l.selectedIDs = make(map[int]bool)
-	l.Status = "waiting"
-	l.Countdown = DefaultCountdownSec
-	l.NumbersDrawn = []string{}
-	l.currentGame = nil
-	l.BingoWinner = nil
-	l.BingoWinnerCardID = nil
-    l.roundPot = 0
-    l.BingoWinnerName = nil
-	l.mu.Unlock() // unlock before broadcast and channel send
+	l.jackpot = balance
+	l.mu.Unlock()
+}
 
-	l.broadcastState()
+type broadcastState struct {
+	Stake             int                   `json:"stake"`
+	Status            string                `json:"status"` // waiting, countdown or in_progress
+	Phase             game.Phase            `json:"phase"`
+	Patterns          []game.Pattern        `json:"patterns"` // what wins this round
+	Countdown         int                   `json:"countdown"`
+	NumbersDrawn      []string              `json:"numbersDrawn"`
+	Cards             map[uint][]int        `json:"cards"`    // each player's first card, for one-card clients
+	Selected          map[uint]int          `json:"selected"` // the ID of that card
+	Held              map[uint][]HeldCard   `json:"held"`     // every card each player holds
+	MaxCards          int                   `json:"maxCards"`
+	AvailableCards    []CardBroadcast       `json:"availableCards"` // send full cards
+	Winners           []RoundWinner         `json:"winners"`
+	Balances          map[uint]models.Money `json:"balances"`
+	PotentialWinnings models.Money          `json:"potentialWinnings,omitempty"`
+	Jackpot           models.Money          `json:"jackpot,omitempty"`
+	Fairness          *fairness             `json:"fairness,omitempty"`
+}
 
-	// Signal auto-round loop
-	l.roundDone <- struct{}{} // **blocking send** guarantees next round starts
+// fairness is what players see of the round's seeds: the commitment from
+// the countdown on, and the server seed once the round is settled.
+type fairness struct {
+	Algorithm      string `json:"algorithm"`
+	ServerSeedHash string `json:"serverSeedHash"`
+	ClientSeed     string `json:"clientSeed"`
+	ServerSeed     string `json:"serverSeed,omitempty"`
 }
 
-// -------------------- Broadcast --------------------
-type broadcastState struct {
-	Stake             int             `json:"stake"`
-	Status            string          `json:"status"`
-	Countdown         int             `json:"countdown"`
-	NumbersDrawn      []string        `json:"numbersDrawn"`
-	Cards             map[uint][]int  `json:"cards"`
-	Selected          map[uint]int    `json:"selected"`
-	AvailableCards    []CardBroadcast `json:"availableCards"` // send full cards
-	BingoWinner       *uint
-	BingoWinnerName   *string         `json:"bingoWinnerName"` 
-	BingoWinnerCardID *int             `json:"bingoWinnerCardId"`
-	Balances          map[uint]float64 `json:"balances"`
-	PotentialWinnings float64          `json:"potentialWinnings,omitempty"`
+// HeldCard is one card a player holds this round.
+type HeldCard struct {
+	CardID  int   `json:"cardId"`
+	Numbers []int `json:"numbers"`
+	Checked bool  `json:"checked"` // already checked for bingo this round
 }
+
 type CardBroadcast struct {
 	CardID int   `json:"card_id"`
 	B      []int `json:"B"`
@@ -619,9 +1031,19 @@ type CardBroadcast struct {
 	Taken  bool  `json:"taken"`
 }
 
+// lobbyStatus is the status players have always been sent: every phase of a
+// running round is in_progress.
+func lobbyStatus(p game.Phase) string {
+	switch p {
+	case game.Waiting, game.Countdown:
+		return string(p)
+	}
+	return "in_progress"
+}
+
 func (l *Lobby) broadcastState() {
 	l.mu.RLock()
-	balances := make(map[uint]float64, len(l.clients))
+	balances := make(map[uint]models.Money, len(l.clients))
 	for userID := range l.clients {
 		var user models.User
 		if err := config.DB.First(&user, userID).Error; err == nil {
@@ -631,23 +1053,55 @@ func (l *Lobby) broadcastState() {
 			log.Printf("[Lobby %d] failed to fetch balance for user %d: %v", l.Stake, userID, err)
 		}
 	}
-	// ✅ Calculate potential winnings dynamically based on current selected users
-	
-	potentialWinnings := l.roundPot
+
+	snap := l.engine.Snapshot()
+	cards := make(map[uint][]int, len(snap.Cards))
+	selected := make(map[uint]int, len(snap.Cards))
+	held := make(map[uint][]HeldCard, len(snap.Cards))
+	for userID, sels := range snap.Cards {
+		cards[userID] = sels[0].Card.Numbers()
+		selected[userID] = sels[0].CardID
+		for _, sel := range sels {
+			held[userID] = append(held[userID], HeldCard{CardID: sel.CardID, Numbers: sel.Card.Numbers(), Checked: snap.Checked[sel.CardID]})
+		}
+	}
+	taken := make(map[int]bool, len(snap.Taken))
+	for _, id := range snap.Taken {
+		taken[id] = true
+	}
+	winners := append([]RoundWinner(nil), l.paid...)
+	if winners == nil {
+		for _, w := range snap.Winners {
+			winners = append(winners, roundWinner(w))
+		}
+	}
 
 	state := broadcastState{
 		Stake:             l.Stake,
-		Status:            l.Status,
-		Countdown:         l.Countdown,
-		NumbersDrawn:      append([]string(nil), l.NumbersDrawn...),
-		Cards:             copyCardsMap(l.Cards),
-		Selected:          copySelectedMap(l.CardIDs),
-		AvailableCards:    copyCardsMapWithTaken(l.selectedIDs), // all cards
-		BingoWinner:       l.BingoWinner,
-		BingoWinnerCardID: l.BingoWinnerCardID, // automatically included
-		BingoWinnerName:   l.BingoWinnerName,  // ✅ now works
-		Balances:          balances,            // ✅ include balances
-		PotentialWinnings: potentialWinnings,
+		Status:            lobbyStatus(snap.Phase),
+		Phase:             snap.Phase,
+		Patterns:          snap.Patterns,
+		Countdown:         snap.Countdown,
+		NumbersDrawn:      ballStrings(snap.Drawn),
+		Cards:             cards,
+		Selected:          selected,
+		Held:              held,
+		MaxCards:          snap.MaxCards,
+		AvailableCards:    copyCardsMapWithTaken(taken), // all cards
+		Winners:           winners,
+		Balances:          balances, // ✅ include balances
+		PotentialWinnings: l.roundPot,
+		Jackpot:           l.jackpot,
+	}
+	if l.fair != nil {
+		state.Fairness = &fairness{
+			Algorithm:      game.FairAlgorithm,
+			ServerSeedHash: l.fair.Commitment(),
+			ClientSeed:     l.fair.ClientSeed(),
+		}
+		if snap.Phase == game.Settled {
+			state.Fairness.ServerSeed = l.fair.ServerSeed
+		}
 	}
 	clients := make([]*Client, 0, len(l.clients))
 	for _, c := range l.clients {
@@ -690,30 +1144,3 @@ func copyCardsMapWithTaken(selectedIDs map[int]bool) []CardBroadcast {
 	}
 	return out
 }
-
-func copyCardsMap(in map[uint][]int) map[uint][]int {
-	out := make(map[uint][]int, len(in))
-	for k, v := range in {
-		out[k] = append([]int(nil), v...)
-	}
-	return out
-}
-
-func copySelectedMap(in map[uint]int) map[uint]int {
-	out := make(map[uint]int, len(in))
-	for k, v := range in {
-		out[k] = v
-	}
-	return out
-}
-
-// -------------------- Helpers --------------------
-func generateBingoNumbers() []int {
-	nums := make([]int, 75)
-	for i := range nums {
-		nums[i] = i + 1
-	}
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	r.Shuffle(len(nums), func(i, j int) { nums[i], nums[j] = nums[j], nums[i] })
-	return nums
-}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bellapacxx/bingo-backend/game"
+	"github.com/bellapacxx/bingo-backend/models"
+)
+
+// stubSettlement replaces the lobby's ledger steps for one test: payouts
+// always fail, voids fail when voidErr is set, and every call is recorded.
+func stubSettlement(t *testing.T, voidErr error) (paid, voided, closed *[]uint) {
+	t.Helper()
+	pay, void, cls := payRound, voidRound, closeRound
+	t.Cleanup(func() { payRound, voidRound, closeRound = pay, void, cls })
+
+	paid, voided, closed = new([]uint), new([]uint), new([]uint)
+	payRound = func(gameID uint, _ models.LobbySettings, _ []RoundWinner) error {
+		*paid = append(*paid, gameID)
+		return errors.New("ledger unavailable")
+	}
+	voidRound = func(gameID uint, _ string) (int, error) {
+		*voided = append(*voided, gameID)
+		return 2, voidErr
+	}
+	closeRound = func(gameID uint) error {
+		*closed = append(*closed, gameID)
+		return nil
+	}
+	return paid, voided, closed
+}
+
+func unpaidLobby() *Lobby {
+	return &Lobby{
+		Stake:       10,
+		clients:     make(map[uint]*Client),
+		holds:       make(map[int]uint),
+		currentGame: &models.Game{ID: 42, Status: "in_progress"},
+		roundPot:    200,
+	}
+}
+
+// A round whose winners can't be paid must never be closed, as closing
+// sweeps the pot into rake and marks the winning entries lost.
+func TestFailedPayoutVoidsInsteadOfClosing(t *testing.T) {
+	paid, voided, closed := stubSettlement(t, nil)
+	l := unpaidLobby()
+	claims := []game.Winner{{Player: 7, CardID: 3, Pattern: game.Classic[0]}}
+
+	l.settleClaims(claims)
+	if len(l.paid) != 0 {
+		t.Errorf("paid = %v after a failed payout, want none", l.paid)
+	}
+	if len(l.unpaid) != 1 || l.unpaid[0].UserID != 7 || l.unpaid[0].Prize != 200 {
+		t.Fatalf("unpaid = %+v, want user 7 owed 200", l.unpaid)
+	}
+
+	l.endRound(game.RoundEnded{})
+	if len(*paid) != 2 {
+		t.Errorf("payout tried %d times, want 2", len(*paid))
+	}
+	if len(*closed) != 0 {
+		t.Errorf("game closed %v after a failed payout", *closed)
+	}
+	if len(*voided) != 1 || (*voided)[0] != 42 {
+		t.Errorf("voided %v, want game 42", *voided)
+	}
+	if l.unpaid != nil || l.currentGame != nil {
+		t.Error("lobby not reset for the next round")
+	}
+}
+
+// If the refund fails too the game is left in_progress for recovery.
+func TestFailedVoidLeavesGameForRecovery(t *testing.T) {
+	_, voided, closed := stubSettlement(t, errors.New("ledger unavailable"))
+	l := unpaidLobby()
+
+	l.settleClaims([]game.Winner{{Player: 7, CardID: 3, Pattern: game.Classic[0]}})
+	l.endRound(game.RoundEnded{})
+	if len(*voided) != 1 {
+		t.Errorf("voided %v, want one attempt", *voided)
+	}
+	if len(*closed) != 0 {
+		t.Errorf("game closed %v after a failed payout and void", *closed)
+	}
+}
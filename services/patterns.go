@@ -0,0 +1,67 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/bellapacxx/bingo-backend/game"
+)
+
+var (
+	Patterns   []game.Pattern // the pattern library, in file order
+	patternsMu sync.RWMutex
+)
+
+// ErrUnknownPattern is returned for a pattern name the library doesn't have.
+var ErrUnknownPattern = errors.New("unknown pattern")
+
+// LoadPatterns loads the winning-pattern library from JSON file
+func LoadPatterns() {
+	data, err := os.ReadFile("patterns.json")
+	if err != nil {
+		log.Fatalf("Failed to read patterns.json: %v", err)
+	}
+	patterns, err := game.ParsePatterns(data)
+	if err != nil {
+		log.Fatalf("Failed to parse patterns.json: %v", err)
+	}
+	patternsMu.Lock()
+	Patterns = patterns
+	patternsMu.Unlock()
+	log.Printf("[Init] Loaded %d winning patterns", len(patterns))
+}
+
+// PatternLibrary returns every pattern a lobby or round can use.
+func PatternLibrary() []game.Pattern {
+	patternsMu.RLock()
+	defer patternsMu.RUnlock()
+	return append([]game.Pattern(nil), Patterns...)
+}
+
+// ResolvePatterns looks the named patterns up in the library. No names
+// gives nil, which the engine plays as the classic set.
+func ResolvePatterns(names []string) ([]game.Pattern, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	patternsMu.RLock()
+	defer patternsMu.RUnlock()
+	out := make([]game.Pattern, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, p := range Patterns {
+			if p.Name == name {
+				out = append(out, p)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownPattern, name)
+		}
+	}
+	return out, nil
+}
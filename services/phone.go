@@ -0,0 +1,46 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/bellapacxx/bingo-backend/models"
+	"gorm.io/gorm"
+)
+
+const nationalDigits = 9
+
+// NormalizePhone returns the national part of a phone number, its last nine
+// digits, so "+251 911 234 567", "251911234567" and "0911234567" are the
+// same number. It returns "" for anything shorter.
+func NormalizePhone(phone string) string {
+	digits := digitsOf(phone)
+	if len(digits) < nationalDigits {
+		return ""
+	}
+	return digits[len(digits)-nationalDigits:]
+}
+
+// PhoneTaken reports whether an account other than userID has this phone
+// number, however either was written. Phones stored before they were
+// normalised are compared the same way.
+func PhoneTaken(tx *gorm.DB, phone string, userID uint) (bool, error) {
+	national := NormalizePhone(phone)
+	if national == "" {
+		return false, nil
+	}
+	var others int64
+	err := tx.Model(&models.User{}).
+		Where("RIGHT(regexp_replace(phone, '[^0-9]', '', 'g'), ?) = ? AND id <> ?", nationalDigits, national, userID).
+		Count(&others).Error
+	return others > 0, err
+}
+
+func digitsOf(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
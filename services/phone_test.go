@@ -0,0 +1,20 @@
+package services
+
+import "testing"
+
+func TestNormalizePhone(t *testing.T) {
+	tests := map[string]string{
+		"0911234567":       "911234567",
+		"+251 911 234 567": "911234567",
+		"251-911-234-567":  "911234567",
+		" 911234567 ":      "911234567",
+		"91123456":         "",
+		"":                 "",
+		"not a phone":      "",
+	}
+	for in, want := range tests {
+		if got := NormalizePhone(in); got != want {
+			t.Errorf("NormalizePhone(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultReferralReward    = 20 * models.SantimPerBirr
+	defaultReferralMinRounds = 3
+)
+
+// ReferralReward is what a referrer earns per qualified player, from
+// REFERRAL_REWARD in birr (default 20).
+func ReferralReward() models.Money {
+	if v := os.Getenv("REFERRAL_REWARD"); v != "" {
+		m, err := models.ParseMoney(v)
+		if err == nil && m >= 0 {
+			return m
+		}
+		log.Printf("[WARN] REFERRAL_REWARD=%q is not an amount, using default", v)
+	}
+	return defaultReferralReward
+}
+
+// ReferralMinRounds is how many settled rounds a referred player must have
+// played, from REFERRAL_MIN_ROUNDS (default 3).
+func ReferralMinRounds() int {
+	if v := os.Getenv("REFERRAL_MIN_ROUNDS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err == nil && n >= 0 {
+			return n
+		}
+		log.Printf("[WARN] REFERRAL_MIN_ROUNDS=%q is not a count, using default", v)
+	}
+	return defaultReferralMinRounds
+}
+
+// ReferralLink is the bot deep link that carries code as the start
+// parameter, or "" when BOT_USERNAME isn't set.
+func ReferralLink(code string) string {
+	bot := strings.TrimPrefix(os.Getenv("BOT_USERNAME"), "@")
+	if bot == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://t.me/%s?start=%s", bot, code)
+}
+
+// EnsureReferralCode gives the player a referral code if they don't have
+// one yet and returns it. Codes are 40 random bits, so a clash with an
+// existing code is left to the unique index to refuse.
+func EnsureReferralCode(tx *gorm.DB, user *models.User) (string, error) {
+	if user.ReferralCode != nil {
+		return *user.ReferralCode, nil
+	}
+	code, err := newReferralCode()
+	if err != nil {
+		return "", err
+	}
+	res := tx.Model(&models.User{}).
+		Where("id = ? AND referral_code IS NULL", user.ID).
+		Update("referral_code", code)
+	if res.Error != nil {
+		return "", res.Error
+	}
+	if res.RowsAffected == 0 {
+		// Set concurrently; use the stored one.
+		if err := tx.First(user, user.ID).Error; err != nil {
+			return "", err
+		}
+		return *user.ReferralCode, nil
+	}
+	user.ReferralCode = &code
+	return code, nil
+}
+
+func newReferralCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "r" + strings.ToLower(base32.StdEncoding.EncodeToString(b)), nil
+}
+
+// AttributeReferral records that a newly registered player arrived through
+// startParam. Unknown codes are ignored. Self-referrals and players whose
+// phone is already on another account are recorded as rejected, so they
+// never earn a reward.
+func AttributeReferral(tx *gorm.DB, user *models.User, startParam string) (*models.Referral, error) {
+	code := strings.TrimSpace(startParam)
+	if code == "" {
+		return nil, nil
+	}
+	var referrer models.User
+	err := tx.Where("referral_code = ?", code).First(&referrer).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ref := &models.Referral{ReferrerID: referrer.ID, ReferredID: user.ID, Code: code, Status: models.ReferralPending}
+	if reason, err := referralProblem(tx, &referrer, user); err != nil {
+		return nil, err
+	} else if reason != "" {
+		ref.Status, ref.Reason = models.ReferralRejected, reason
+	}
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(ref)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, nil // already referred
+	}
+	return ref, nil
+}
+
+// referralProblem returns why a referral can't be rewarded, or "".
+func referralProblem(tx *gorm.DB, referrer, referred *models.User) (string, error) {
+	if referrer.ID == referred.ID || referrer.TelegramID == referred.TelegramID {
+		return "self-referral", nil
+	}
+	phone := NormalizePhone(referred.Phone)
+	if phone == "" {
+		return "", nil
+	}
+	if phone == NormalizePhone(referrer.Phone) {
+		return "self-referral: same phone as referrer", nil
+	}
+	taken, err := PhoneTaken(tx, phone, referred.ID)
+	if err != nil {
+		return "", err
+	}
+	if taken {
+		return "phone is used by another account", nil
+	}
+	return "", nil
+}
+
+// qualifyReferral pays the referrer once the referred player has made a
+// deposit and played ReferralMinRounds settled rounds. The phone checks run
+// again here, since the phone is usually added after registration. It
+// returns the referral when this call rewarded it.
+func qualifyReferral(tx *gorm.DB, userID uint) (*models.Referral, error) {
+	var ref models.Referral
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("referred_id = ? AND status = ?", userID, models.ReferralPending).
+		Limit(1).Find(&ref).Error
+	if err != nil || ref.ID == 0 {
+		return nil, err
+	}
+
+	var deposits int64
+	if err := tx.Model(&models.Deposit{}).
+		Where("user_id = ? AND status = ?", userID, models.DepositCredited).
+		Count(&deposits).Error; err != nil {
+		return nil, err
+	}
+	if deposits == 0 {
+		return nil, nil
+	}
+	var rounds int64
+	if err := tx.Model(&models.RoundEntry{}).
+		Where("user_id = ? AND status IN ?", userID, []models.RoundEntryStatus{models.EntryWon, models.EntryLost}).
+		Distinct("game_id").Count(&rounds).Error; err != nil {
+		return nil, err
+	}
+	if rounds < int64(ReferralMinRounds()) {
+		return nil, nil
+	}
+
+	var referrer, referred models.User
+	if err := tx.First(&referrer, ref.ReferrerID).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.First(&referred, userID).Error; err != nil {
+		return nil, err
+	}
+	reason, err := referralProblem(tx, &referrer, &referred)
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		ref.Status, ref.Reason = models.ReferralRejected, reason
+		return nil, tx.Save(&ref).Error
+	}
+
+	now := time.Now()
+	ref.Status = models.ReferralRewarded
+	ref.Reward = ReferralReward()
+	ref.RewardedAt = &now
+	if ref.Reward > 0 {
+		funding, err := SystemAccount(tx, ReferralsCode, models.EquityAccount)
+		if err != nil {
+			return nil, err
+		}
+		wallet, err := WalletAccount(tx, referrer.ID)
+		if err != nil {
+			return nil, err
+		}
+		entry := &models.JournalEntry{Kind: models.ReferralRewardEntry, UserID: &referrer.ID, Reference: referralRef(ref.ID)}
+		if err := Transfer(tx, entry, funding, wallet, ref.Reward); err != nil {
+			return nil, err
+		}
+		if err := recordTransaction(tx, wallet, models.ReferralTransaction, ref.Reward, nil, entry.Reference); err != nil {
+			return nil, err
+		}
+	}
+	return &ref, tx.Save(&ref).Error
+}
+
+// rewardReferrals checks every player of a settled game for a referral
+// that has now qualified.
+func rewardReferrals(gameID uint) {
+	var users []uint
+	if err := config.DB.Model(&models.RoundEntry{}).Where("game_id = ?", gameID).
+		Distinct().Pluck("user_id", &users).Error; err != nil {
+		log.Printf("[Referral] failed to load players of game %d: %v", gameID, err)
+		return
+	}
+	for _, userID := range users {
+		var ref *models.Referral
+		err := WalletTx(config.DB, func(tx *gorm.DB) error {
+			var err error
+			ref, err = qualifyReferral(tx, userID)
+			return err
+		})
+		if err != nil {
+			log.Printf("[Referral] failed to check referral of user %d: %v", userID, err)
+			continue
+		}
+		if ref != nil && ref.Reward > 0 {
+			NotifyPromotion(ref.ReferrerID, "🤝 A friend you invited is playing! You earned "+ref.Reward.String())
+		}
+	}
+}
+
+// ReferralStats summarises a player's referrals.
+type ReferralStats struct {
+	Code      string       `json:"code"`
+	Link      string       `json:"link,omitempty"`
+	Invited   int64        `json:"invited"`
+	Pending   int64        `json:"pending"`
+	Rewarded  int64        `json:"rewarded"`
+	Rejected  int64        `json:"rejected"`
+	Earned    models.Money `json:"earned"`
+	Reward    models.Money `json:"reward"`    // paid per qualified player
+	MinRounds int          `json:"minRounds"` // rounds a referred player must play
+}
+
+// UserReferralStats returns the player's code, link and referral counts.
+func UserReferralStats(tx *gorm.DB, user *models.User) (*ReferralStats, error) {
+	code, err := EnsureReferralCode(tx, user)
+	if err != nil {
+		return nil, err
+	}
+	stats := &ReferralStats{Code: code, Link: ReferralLink(code), Reward: ReferralReward(), MinRounds: ReferralMinRounds()}
+
+	var rows []struct {
+		Status models.ReferralStatus
+		Count  int64
+		Reward models.Money
+	}
+	if err := tx.Model(&models.Referral{}).
+		Select("status, COUNT(*) AS count, COALESCE(SUM(reward), 0) AS reward").
+		Where("referrer_id = ?", user.ID).Group("status").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		stats.Invited += r.Count
+		switch r.Status {
+		case models.ReferralPending:
+			stats.Pending = r.Count
+		case models.ReferralRewarded:
+			stats.Rewarded = r.Count
+			stats.Earned = r.Reward
+		case models.ReferralRejected:
+			stats.Rejected = r.Count
+		}
+	}
+	return stats, nil
+}
+
+func referralRef(id uint) string {
+	return fmt.Sprintf("referral:%d", id)
+}
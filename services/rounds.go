@@ -0,0 +1,178 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrGameNotInProgress = errors.New("game is not in progress")
+	ErrGameHasWinners    = errors.New("game already paid a winner")
+)
+
+// VoidGame cancels an unsettled game and refunds every paid entry from the
+// game pot. A game that has paid any winner can't be voided. It only touches the database; a lobby still running the game
+// must be stopped first (see Lobby.AbortRound).
+func VoidGame(gameID uint, reason string) (refunded int, err error) {
+	err = WalletTx(config.DB, func(tx *gorm.DB) error {
+		var game models.Game
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&game, gameID).Error; err != nil {
+			return err
+		}
+		if game.Status != "in_progress" {
+			return fmt.Errorf("%w: game %d is %s", ErrGameNotInProgress, gameID, game.Status)
+		}
+
+		var won int64
+		if err := tx.Model(&models.RoundEntry{}).Where("game_id = ? AND status = ?", gameID, models.EntryWon).Count(&won).Error; err != nil {
+			return err
+		}
+		if won > 0 {
+			return fmt.Errorf("%w: game %d", ErrGameHasWinners, gameID)
+		}
+
+		var entries []models.RoundEntry
+		if err := tx.Where("game_id = ? AND status = ?", gameID, models.EntryPaid).Find(&entries).Error; err != nil {
+			return err
+		}
+		pot, err := GamePotAccount(tx, gameID)
+		if err != nil {
+			return err
+		}
+		if err := reverseRake(tx, gameID, pot); err != nil {
+			return err
+		}
+		if err := returnJackpotFeed(tx, gameID, game.Stake, pot); err != nil {
+			return err
+		}
+		for i := range entries {
+			if err := refundEntry(tx, pot, &entries[i]); err != nil {
+				return err
+			}
+		}
+		refunded = len(entries)
+
+		res := tx.Model(&models.Game{}).
+			Where("id = ? AND status = ?", gameID, "in_progress").
+			Updates(map[string]any{"status": "voided", "void_reason": reason, "end_time": time.Now()})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("%w: game %d", ErrGameNotInProgress, gameID)
+		}
+		return nil
+	})
+	return refunded, err
+}
+
+func refundEntry(tx *gorm.DB, pot *models.LedgerAccount, e *models.RoundEntry) error {
+	wallet, err := WalletAccount(tx, e.UserID)
+	if err != nil {
+		return err
+	}
+	bonus, err := BonusWalletAccount(tx, e.UserID)
+	if err != nil {
+		return err
+	}
+	entry := &models.JournalEntry{
+		Kind:      models.RefundEntry,
+		UserID:    &e.UserID,
+		GameID:    &e.GameID,
+		Reference: fmt.Sprintf("round_entry:%d", e.ID),
+	}
+	// Each part goes back to the bucket it was paid from. Wagering already
+	// counted for the stake is kept.
+	cash := e.Stake - e.Bonus
+	if err := PostEntry(tx, entry, bucketLegs(wallet, bonus, pot, cash, e.Bonus, 1)...); err != nil {
+		return err
+	}
+	e.Status = models.EntryRefunded
+	if err := tx.Save(e).Error; err != nil {
+		return err
+	}
+	if cash == 0 {
+		return nil
+	}
+	return recordTransaction(tx, wallet, models.RefundTransaction, cash, &e.GameID, entry.Reference)
+}
+
+// markWinningEntry flags the winning card's entry once it has been paid.
+func markWinningEntry(tx *gorm.DB, gameID uint, cardID int) error {
+	return tx.Model(&models.RoundEntry{}).
+		Where("game_id = ? AND card_id = ? AND status = ?", gameID, cardID, models.EntryPaid).
+		Update("status", models.EntryWon).Error
+}
+
+// markLosingEntries closes every entry of a settled game that didn't win.
+func markLosingEntries(tx *gorm.DB, gameID uint) error {
+	return tx.Model(&models.RoundEntry{}).
+		Where("game_id = ? AND status = ?", gameID, models.EntryPaid).
+		Update("status", models.EntryLost).Error
+}
+
+// RecoverInterruptedRounds runs at startup, before any lobby starts. Games
+// still marked in_progress lost their in-memory state when the process
+// stopped, so they can't be resumed fairly: games that already paid a winner
+// are closed out, and all others are voided with full refunds.
+func RecoverInterruptedRounds() {
+	var games []models.Game
+	if err := config.DB.Where("status = ?", "in_progress").Find(&games).Error; err != nil {
+		log.Printf("[Recovery] failed to load interrupted games: %v", err)
+		return
+	}
+
+	for _, game := range games {
+		var winners int64
+		if err := config.DB.Model(&models.RoundEntry{}).
+			Where("game_id = ? AND status = ?", game.ID, models.EntryWon).
+			Count(&winners).Error; err != nil {
+			log.Printf("[Recovery] failed to check game %d: %v", game.ID, err)
+			continue
+		}
+
+		if winners > 0 {
+			if err := closeSettledGame(game.ID); err != nil {
+				log.Printf("[Recovery] failed to close game %d: %v", game.ID, err)
+			} else {
+				log.Printf("[Recovery] closed game %d (stake %d), winner was already paid", game.ID, game.Stake)
+			}
+			continue
+		}
+
+		refunded, err := VoidGame(game.ID, "server restarted mid-round")
+		if err != nil {
+			log.Printf("[Recovery] failed to void game %d: %v", game.ID, err)
+			continue
+		}
+		log.Printf("[Recovery] voided game %d (stake %d), refunded %d entries", game.ID, game.Stake, refunded)
+	}
+}
+
+// closeSettledGame finishes a game whose payout already happened: the rest
+// of the pot is booked as rake and the losing entries are closed. Referrals
+// of its players that have now qualified are rewarded afterwards.
+func closeSettledGame(gameID uint) error {
+	if err := sweepPot(gameID); err != nil {
+		return err
+	}
+	err := WalletTx(config.DB, func(tx *gorm.DB) error {
+		if err := markLosingEntries(tx, gameID); err != nil {
+			return err
+		}
+		return tx.Model(&models.Game{}).Where("id = ?", gameID).
+			Updates(map[string]any{"status": "finished", "end_time": time.Now()}).Error
+	})
+	if err != nil {
+		return err
+	}
+	rewardReferrals(gameID)
+	return nil
+}
@@ -0,0 +1,21 @@
+package services
+
+import (
+	"github.com/bellapacxx/bingo-backend/models"
+	"gorm.io/gorm"
+)
+
+// recordTransaction adds the player's history line for a wallet movement
+// that was just posted. wallet must be the account the entry moved, cash or
+// bonus wallet, so its cached balance is the balance after the movement.
+func recordTransaction(tx *gorm.DB, wallet *models.LedgerAccount, typ models.TransactionType, amount models.Money, gameID *uint, ref string) error {
+	return tx.Create(&models.Transaction{
+		UserID:       *wallet.UserID,
+		Type:         typ,
+		Amount:       amount,
+		Wallet:       wallet.Type,
+		BalanceAfter: wallet.Balance,
+		GameID:       gameID,
+		Reference:    ref,
+	}).Error
+}
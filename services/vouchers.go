@@ -0,0 +1,316 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// voucherAlphabet leaves out 0/O and 1/I so printed codes read back
+	// unambiguously; 16 characters give 80 bits.
+	voucherAlphabet   = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+	voucherCodeLength = 16
+	maxVoucherBatch   = 10000
+
+	// A player or address with this many failed attempts inside
+	// VoucherLockout can't redeem until the window has passed.
+	VoucherMaxFailures = 5
+	VoucherLockout     = 15 * time.Minute
+)
+
+var (
+	ErrInvalidVoucherBatch = errors.New("invalid voucher batch")
+	ErrVoucherInvalid      = errors.New("voucher code is not valid")
+	ErrVoucherExpired      = errors.New("voucher has expired")
+	ErrVoucherUsedUp       = errors.New("voucher has already been used")
+	ErrVoucherRepeated     = errors.New("you have already redeemed this voucher")
+	ErrVoucherLocked       = errors.New("too many failed voucher attempts, try again later")
+)
+
+// VoucherBatchRequest describes a batch to generate.
+type VoucherBatchRequest struct {
+	Name      string
+	Amount    models.Money
+	Count     int
+	MaxUses   int
+	ExpiresAt time.Time
+	CreatedBy string
+}
+
+// CreateVoucherBatch generates req.Count random codes in one batch.
+func CreateVoucherBatch(req VoucherBatchRequest) (*models.VoucherBatch, error) {
+	switch {
+	case req.Amount <= 0:
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidVoucherBatch)
+	case req.Count <= 0 || req.Count > maxVoucherBatch:
+		return nil, fmt.Errorf("%w: count must be between 1 and %d", ErrInvalidVoucherBatch, maxVoucherBatch)
+	case req.MaxUses <= 0:
+		return nil, fmt.Errorf("%w: max uses must be positive", ErrInvalidVoucherBatch)
+	case !req.ExpiresAt.After(time.Now()):
+		return nil, fmt.Errorf("%w: expiry must be in the future", ErrInvalidVoucherBatch)
+	}
+
+	batch := &models.VoucherBatch{
+		Name:      strings.TrimSpace(req.Name),
+		Amount:    req.Amount,
+		Count:     req.Count,
+		MaxUses:   req.MaxUses,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: req.CreatedBy,
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(batch).Error; err != nil {
+			return err
+		}
+		vouchers := make([]models.Voucher, 0, req.Count)
+		for i := 0; i < req.Count; i++ {
+			code, err := newVoucherCode()
+			if err != nil {
+				return err
+			}
+			vouchers = append(vouchers, models.Voucher{
+				BatchID:   batch.ID,
+				Code:      code,
+				Amount:    batch.Amount,
+				MaxUses:   batch.MaxUses,
+				ExpiresAt: batch.ExpiresAt,
+			})
+		}
+		return tx.CreateInBatches(vouchers, 500).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+func newVoucherCode() (string, error) {
+	size := big.NewInt(int64(len(voucherAlphabet)))
+	b := make([]byte, voucherCodeLength)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", err
+		}
+		b[i] = voucherAlphabet[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// NormalizeVoucherCode upper-cases a code and drops dashes and spaces, so
+// players can type it the way it is printed or not.
+func NormalizeVoucherCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// FormatVoucherCode prints a code in groups of four.
+func FormatVoucherCode(code string) string {
+	var parts []string
+	for len(code) > 4 {
+		parts = append(parts, code[:4])
+		code = code[4:]
+	}
+	return strings.Join(append(parts, code), "-")
+}
+
+// WriteVoucherCSV writes every code of a batch with its usage so far.
+func WriteVoucherCSV(w io.Writer, batchID uint) error {
+	var vouchers []models.Voucher
+	if err := config.DB.Where("batch_id = ?", batchID).Order("id").Find(&vouchers).Error; err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"code", "amount", "max_uses", "uses", "expires_at"}); err != nil {
+		return err
+	}
+	for _, v := range vouchers {
+		rec := []string{
+			FormatVoucherCode(v.Code),
+			v.Amount.Decimal(),
+			strconv.Itoa(v.MaxUses),
+			strconv.Itoa(v.Uses),
+			v.ExpiresAt.Format(time.RFC3339),
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// RedeemVoucher credits a voucher to the player's wallet as a deposit from
+// the "voucher" provider, so deposit limits, self-exclusion and the
+// transaction history apply as for any deposit. Every attempt is written to
+// the audit trail, and players or addresses with too many recent failures
+// are locked out. The redemption runs in a transaction of its own in which
+// attempts by the same player or from the same address wait for each other,
+// so concurrent guesses can't all pass the lockout check before any failure
+// is recorded.
+func RedeemVoucher(db *gorm.DB, userID uint, code, ip string) (*models.Deposit, error) {
+	var deposit *models.Deposit
+	var redeemErr error
+	err := db.Transaction(func(tx *gorm.DB) error {
+		deposit, redeemErr = redeemVoucher(tx, userID, code, ip)
+		if _, known := voucherResult(redeemErr); known || errors.Is(redeemErr, ErrVoucherLocked) {
+			return nil // the attempt was audited; keep it
+		}
+		return redeemErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deposit, redeemErr
+}
+
+func redeemVoucher(tx *gorm.DB, userID uint, code, ip string) (*models.Deposit, error) {
+	code = NormalizeVoucherCode(code)
+	attempt := &models.VoucherAttempt{UserID: userID, Code: code, IP: ip}
+
+	if err := lockVoucherAttempts(tx, userID, ip); err != nil {
+		return nil, err
+	}
+	failures, err := recentVoucherFailures(tx, userID, ip)
+	if err != nil {
+		return nil, err
+	}
+	if failures >= VoucherMaxFailures {
+		attempt.Result = models.VoucherLocked
+		if err := tx.Create(attempt).Error; err != nil {
+			return nil, err
+		}
+		return nil, ErrVoucherLocked
+	}
+
+	var deposit *models.Deposit
+	err = WalletTx(tx, func(tx *gorm.DB) error {
+		var v models.Voucher
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", code).First(&v).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) || code == "" {
+			return ErrVoucherInvalid
+		}
+		if err != nil {
+			return err
+		}
+		attempt.VoucherID = &v.ID
+		switch {
+		case !time.Now().Before(v.ExpiresAt):
+			return ErrVoucherExpired
+		case v.Uses >= v.MaxUses:
+			return ErrVoucherUsedUp
+		}
+		var repeated int64
+		if err := tx.Model(&models.VoucherRedemption{}).
+			Where("voucher_id = ? AND user_id = ?", v.ID, userID).Count(&repeated).Error; err != nil {
+			return err
+		}
+		if repeated > 0 {
+			return ErrVoucherRepeated
+		}
+
+		deposit = &models.Deposit{
+			UserID:         userID,
+			Amount:         v.Amount,
+			ExpectedAmount: v.Amount,
+			Reference:      fmt.Sprintf("voucher:%d:%d", v.ID, userID),
+			Provider:       models.VoucherProvider,
+			Status:         models.DepositCredited,
+		}
+		if err := RecordDeposit(tx, deposit); err != nil {
+			return err
+		}
+		if err := tx.Model(&v).Update("uses", gorm.Expr("uses + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.VoucherRedemption{
+			VoucherID: v.ID,
+			UserID:    userID,
+			DepositID: deposit.ID,
+			Amount:    v.Amount,
+		}).Error
+	})
+
+	result, known := voucherResult(err)
+	if !known {
+		return nil, err // database trouble; nothing to audit
+	}
+	attempt.Result = result
+	if auditErr := tx.Create(attempt).Error; auditErr != nil {
+		return nil, auditErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return deposit, nil
+}
+
+// voucherResult maps a redemption outcome to its audit result. known is
+// false for errors that say nothing about the code.
+func voucherResult(err error) (result models.VoucherResult, known bool) {
+	var limitErr *LimitError
+	var excluded *ExcludedError
+	switch {
+	case err == nil:
+		return models.VoucherRedeemed, true
+	case errors.Is(err, ErrVoucherInvalid):
+		return models.VoucherInvalid, true
+	case errors.Is(err, ErrVoucherExpired):
+		return models.VoucherExpired, true
+	case errors.Is(err, ErrVoucherUsedUp):
+		return models.VoucherUsedUp, true
+	case errors.Is(err, ErrVoucherRepeated):
+		return models.VoucherRepeated, true
+	case errors.As(err, &limitErr), errors.As(err, &excluded):
+		return models.VoucherRefused, true
+	}
+	return "", false
+}
+
+// lockVoucherAttempts takes transaction-scoped advisory locks on the player
+// and the address, always in that order so two attempts can't deadlock.
+func lockVoucherAttempts(tx *gorm.DB, userID uint, ip string) error {
+	keys := []string{fmt.Sprintf("voucher:user:%d", userID)}
+	if ip != "" {
+		keys = append(keys, "voucher:ip:"+ip)
+	}
+	for _, key := range keys {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", key).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recentVoucherFailures counts failed code guesses by the player or from
+// ip inside VoucherLockout. Refusals for limits don't count; they say
+// nothing about guessing.
+func recentVoucherFailures(tx *gorm.DB, userID uint, ip string) (int64, error) {
+	var n int64
+	q := tx.Model(&models.VoucherAttempt{}).
+		Where("created_at > ? AND result IN ?", time.Now().Add(-VoucherLockout),
+			[]models.VoucherResult{models.VoucherInvalid, models.VoucherExpired, models.VoucherUsedUp, models.VoucherRepeated})
+	if ip != "" {
+		q = q.Where("(user_id = ? OR ip = ?)", userID, ip)
+	} else {
+		q = q.Where("user_id = ?", userID)
+	}
+	err := q.Count(&n).Error
+	return n, err
+}
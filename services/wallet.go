@@ -0,0 +1,54 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Every change to a wallet goes through the ledger (PostEntry/Transfer),
+// which locks the affected account rows FOR UPDATE, in ID order, before
+// reading their balances and moves users.balance with an in-place
+// increment. Nothing loads a balance, changes it in Go and saves it back, so
+// concurrent deposits, stakes and payouts can't overwrite one another.
+
+// walletTxAttempts bounds how often WalletTx retries a conflicting transaction.
+const walletTxAttempts = 5
+
+// WalletTx runs fn in a transaction that moves wallet money. When db is not
+// already inside a transaction, deadlocks and serialization failures are
+// retried with a short backoff. Inside a caller's transaction fn runs in a
+// savepoint and conflicts are returned, since only the owner of the
+// transaction can retry it (idempotent requests are safe to resend with the
+// same key: failed attempts aren't stored).
+func WalletTx(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); inTx {
+		return db.Transaction(fn)
+	}
+
+	var err error
+	for attempt := 1; attempt <= walletTxAttempts; attempt++ {
+		if err = db.Transaction(fn); err == nil || !isRetryable(err) {
+			return err
+		}
+		log.Printf("[Wallet] transaction conflict (attempt %d/%d): %v", attempt, walletTxAttempts, err)
+		time.Sleep(time.Duration(attempt*attempt) * 10 * time.Millisecond)
+	}
+	return err
+}
+
+// isRetryable reports whether Postgres aborted the transaction because of a
+// conflict with another one, rather than a problem with the transaction.
+func isRetryable(err error) bool {
+	var pgErr interface{ SQLState() string }
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	switch pgErr.SQLState() {
+	case "40001", "40P01": // serialization_failure, deadlock_detected
+		return true
+	}
+	return false
+}
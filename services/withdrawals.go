@@ -0,0 +1,160 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidTransition = errors.New("withdrawal cannot move to that state")
+
+// withdrawalTransitions lists the states each withdrawal state may move to.
+var withdrawalTransitions = map[models.WithdrawalStatus][]models.WithdrawalStatus{
+	models.WithdrawalRequested: {models.WithdrawalApproved, models.WithdrawalRejected, models.WithdrawalCancelled},
+	models.WithdrawalApproved:  {models.WithdrawalPaid, models.WithdrawalRejected},
+}
+
+// WithdrawalUpdate carries the details an operator or player attaches to a
+// state change.
+type WithdrawalUpdate struct {
+	PayoutReference string // required when marking paid
+	Reason          string // why it was rejected
+	ReviewedBy      string
+}
+
+// RequestWithdrawal moves amount from the user's wallet into pending
+// withdrawals and opens a request for an operator to review.
+func RequestWithdrawal(tx *gorm.DB, userID uint, amount models.Money, method, account string) (*models.WithdrawalRequest, error) {
+	req := &models.WithdrawalRequest{
+		UserID:  userID,
+		Amount:  amount,
+		Method:  strings.TrimSpace(method),
+		Account: strings.TrimSpace(account),
+		Status:  models.WithdrawalRequested,
+	}
+	err := WalletTx(tx, func(tx *gorm.DB) error {
+		if err := tx.Create(req).Error; err != nil {
+			return err
+		}
+		wallet, err := WalletAccount(tx, userID)
+		if err != nil {
+			return err
+		}
+		pending, err := SystemAccount(tx, PendingWithdrawalsCode, models.PendingWithdrawalAccount)
+		if err != nil {
+			return err
+		}
+		entry := &models.JournalEntry{Kind: models.WithdrawalEntry, UserID: &userID, Reference: withdrawalRef(req.ID)}
+		if err := Transfer(tx, entry, wallet, pending, amount); err != nil {
+			return err
+		}
+		return recordTransaction(tx, wallet, models.WithdrawTransaction, amount, nil, entry.Reference)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// TransitionWithdrawal moves a request to a new state and makes the matching
+// ledger postings: paid sends the held money out, rejected and cancelled
+// return it to the wallet.
+func TransitionWithdrawal(tx *gorm.DB, id uint, to models.WithdrawalStatus, upd WithdrawalUpdate) (*models.WithdrawalRequest, error) {
+	var req models.WithdrawalRequest
+	err := WalletTx(tx, func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&req, id).Error; err != nil {
+			return err
+		}
+		if !canTransition(req.Status, to) {
+			return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, req.Status, to)
+		}
+
+		now := time.Now()
+		req.Status = to
+		if upd.ReviewedBy != "" {
+			req.ReviewedBy = upd.ReviewedBy
+		}
+
+		switch to {
+		case models.WithdrawalApproved:
+			req.ApprovedAt = &now
+		case models.WithdrawalPaid:
+			if upd.PayoutReference == "" {
+				return errors.New("payout reference is required")
+			}
+			req.PayoutReference = upd.PayoutReference
+			req.ResolvedAt = &now
+			if err := payOutWithdrawal(tx, &req); err != nil {
+				return err
+			}
+		case models.WithdrawalRejected, models.WithdrawalCancelled:
+			req.Reason = upd.Reason
+			req.ResolvedAt = &now
+			if err := returnWithdrawal(tx, &req); err != nil {
+				return err
+			}
+		}
+		return tx.Save(&req).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func canTransition(from, to models.WithdrawalStatus) bool {
+	for _, s := range withdrawalTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+func payOutWithdrawal(tx *gorm.DB, req *models.WithdrawalRequest) error {
+	pending, err := SystemAccount(tx, PendingWithdrawalsCode, models.PendingWithdrawalAccount)
+	if err != nil {
+		return err
+	}
+	payouts, err := SystemAccount(tx, ExternalPayoutsCode, models.ExternalAccount)
+	if err != nil {
+		return err
+	}
+	entry := &models.JournalEntry{
+		Kind:      models.WithdrawalPaidEntry,
+		UserID:    &req.UserID,
+		Reference: withdrawalRef(req.ID),
+		Memo:      req.PayoutReference,
+	}
+	return Transfer(tx, entry, pending, payouts, req.Amount)
+}
+
+func returnWithdrawal(tx *gorm.DB, req *models.WithdrawalRequest) error {
+	pending, err := SystemAccount(tx, PendingWithdrawalsCode, models.PendingWithdrawalAccount)
+	if err != nil {
+		return err
+	}
+	wallet, err := WalletAccount(tx, req.UserID)
+	if err != nil {
+		return err
+	}
+	entry := &models.JournalEntry{
+		Kind:      models.WithdrawalReturnEntry,
+		UserID:    &req.UserID,
+		Reference: withdrawalRef(req.ID),
+		Memo:      string(req.Status),
+	}
+	if err := Transfer(tx, entry, pending, wallet, req.Amount); err != nil {
+		return err
+	}
+	return recordTransaction(tx, wallet, models.WithdrawReturnTransaction, req.Amount, nil, entry.Reference)
+}
+
+func withdrawalRef(id uint) string {
+	return fmt.Sprintf("withdrawal:%d", id)
+}
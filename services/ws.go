@@ -1,6 +1,8 @@
 package services
 
 import (
+	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -44,6 +46,20 @@ func HandleWebSocket(c *gin.Context) {
 		return
 	}
 
+	if err := CheckNotExcluded(config.DB, user.ID); err != nil {
+		var excluded *ExcludedError
+		if errors.As(err, &excluded) {
+			payload, _ := json.Marshal(map[string]string{"type": "notification", "message": "🛑 " + excluded.Error()})
+			_ = conn.WriteMessage(websocket.TextMessage, payload)
+			_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "self-excluded"))
+			log.Printf("[WS] refused self-excluded user %d", user.ID)
+		} else {
+			log.Printf("[WS] failed to check exclusion for user %d: %v", user.ID, err)
+		}
+		conn.Close()
+		return
+	}
+
 	client := &Client{
 		userID: user.ID,
 		conn:   conn,
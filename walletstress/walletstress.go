@@ -0,0 +1,170 @@
+// Package walletstress hammers player wallets with concurrent deposits,
+// stakes and payouts, then checks that every balance is exactly what the
+// operations that succeeded add up to. cmd/walletstress runs it on demand
+// and the package test runs it against TEST_DATABASE_URL.
+package walletstress
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/bellapacxx/bingo-backend/config"
+	"github.com/bellapacxx/bingo-backend/models"
+	"github.com/bellapacxx/bingo-backend/services"
+	"gorm.io/gorm"
+)
+
+// Options sizes a run.
+type Options struct {
+	Users   int // players to create
+	Workers int // concurrent goroutines
+	Ops     int // operations per worker
+}
+
+// Result is what a run found. Running out of funds is expected and not a
+// failure; anything else is.
+type Result struct {
+	Operations int
+	Failures   []error  // operations that failed unexpectedly
+	Mismatches []string // accounts whose balance isn't what the operations add up to
+	Unbalanced []uint   // journal entries whose postings don't sum to zero
+}
+
+// OK reports whether the run found nothing wrong.
+func (r *Result) OK() bool {
+	return len(r.Failures) == 0 && len(r.Mismatches) == 0 && len(r.Unbalanced) == 0
+}
+
+// Run creates a game and opts.Users players in config.DB, which must be a
+// disposable database, and runs the operations on them. The error is for
+// trouble setting up or checking the run; what the run found is in Result.
+func Run(opts Options) (*Result, error) {
+	db := config.DB
+	run := time.Now().UnixNano()
+
+	game := models.Game{Stake: 10, Status: "in_progress", StartTime: time.Now()}
+	if err := db.Create(&game).Error; err != nil {
+		return nil, err
+	}
+	ids := make([]uint, opts.Users)
+	for i := range ids {
+		u := models.User{TelegramID: -(run%1e9)*1000 - int64(i), Name: fmt.Sprintf("stress-%d-%d", run, i)}
+		if err := db.Create(&u).Error; err != nil {
+			return nil, err
+		}
+		ids[i] = u.ID
+	}
+
+	var (
+		mu       sync.Mutex
+		expected = make(map[uint]models.Money, len(ids))
+		pot      models.Money
+		result   = &Result{Operations: opts.Workers * opts.Ops}
+	)
+	apply := func(userID uint, wallet, toPot models.Money) {
+		mu.Lock()
+		expected[userID] += wallet
+		pot += toPot
+		mu.Unlock()
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(run + int64(w)))
+			for i := 0; i < opts.Ops; i++ {
+				userID := ids[rng.Intn(len(ids))]
+				amount := models.Money(rng.Intn(5000) + 1)
+
+				var err error
+				switch rng.Intn(3) {
+				case 0: // deposit
+					err = services.RecordDeposit(db, &models.Deposit{
+						UserID:    userID,
+						Amount:    amount,
+						Reference: fmt.Sprintf("stress-%d-%d-%d", run, w, i),
+						Provider:  "stress",
+					})
+					if err == nil {
+						apply(userID, amount, 0)
+					}
+				case 1: // stake into the game pot; a held stake has left the wallet
+					var hold *models.StakeHold
+					if hold, err = services.PlaceHold(userID, game.Stake, 0, amount); err == nil {
+						apply(userID, -amount, 0)
+						if err = services.CaptureHold(hold.ID, game.ID); err == nil {
+							apply(userID, 0, amount)
+						}
+					}
+				case 2: // payout from the game pot
+					err = services.WalletTx(db, func(tx *gorm.DB) error {
+						from, err := services.GamePotAccount(tx, game.ID)
+						if err != nil {
+							return err
+						}
+						wallet, err := services.WalletAccount(tx, userID)
+						if err != nil {
+							return err
+						}
+						entry := &models.JournalEntry{Kind: models.PayoutEntry, UserID: &userID, GameID: &game.ID}
+						return services.Transfer(tx, entry, from, wallet, amount)
+					})
+					if err == nil {
+						apply(userID, amount, -amount)
+					}
+				}
+				if err != nil && !errors.Is(err, services.ErrInsufficientFunds) {
+					mu.Lock()
+					result.Failures = append(result.Failures, fmt.Errorf("worker %d op %d: %w", w, i, err))
+					mu.Unlock()
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for _, userID := range ids {
+		var user models.User
+		if err := db.First(&user, userID).Error; err != nil {
+			return nil, err
+		}
+		wallet, err := services.WalletAccount(db, userID)
+		if err != nil {
+			return nil, err
+		}
+		cached, derived, err := services.VerifyAccount(db, wallet.ID)
+		if err != nil {
+			return nil, err
+		}
+		want := expected[userID]
+		if user.Balance != want || cached != want || derived != want {
+			result.Mismatches = append(result.Mismatches, fmt.Sprintf("user %d: expected %s, users.balance %s, wallet %s, postings %s",
+				userID, want, user.Balance, cached, derived))
+		}
+	}
+
+	potAccount, err := services.GamePotAccount(db, game.ID)
+	if err != nil {
+		return nil, err
+	}
+	cached, derived, err := services.VerifyAccount(db, potAccount.ID)
+	if err != nil {
+		return nil, err
+	}
+	if cached != pot || derived != pot {
+		result.Mismatches = append(result.Mismatches, fmt.Sprintf("game pot %d: expected %s, cached %s, postings %s",
+			game.ID, pot, cached, derived))
+	}
+
+	if err := db.Model(&models.LedgerPosting{}).
+		Group("entry_id").Having("SUM(amount) <> 0").
+		Pluck("entry_id", &result.Unbalanced).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,33 @@
+package walletstress
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bellapacxx/bingo-backend/config"
+)
+
+// The database named by TEST_DATABASE_URL must be a disposable one: the
+// schema is migrated and rows are left behind.
+func TestConcurrentWalletOperations(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	t.Setenv("DATABASE_URL", dsn)
+	config.SetupDatabase()
+
+	result, err := Run(Options{Users: 5, Workers: 12, Ops: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range result.Failures {
+		t.Error(err)
+	}
+	for _, m := range result.Mismatches {
+		t.Error(m)
+	}
+	if len(result.Unbalanced) > 0 {
+		t.Errorf("journal entries whose postings don't sum to zero: %v", result.Unbalanced)
+	}
+}